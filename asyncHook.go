@@ -0,0 +1,228 @@
+package pdalog
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// OverflowPolicy decides what AsyncHook does when its queue is full
+type OverflowPolicy int
+
+const (
+	// OverflowBlock makes the logging goroutine wait for free space in the queue
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropNewest discards the entry being logged
+	OverflowDropNewest
+	// OverflowDropOldest discards the oldest queued entry to make room
+	OverflowDropOldest
+)
+
+// ErrHookClosed is returned when firing an AsyncHook that has been closed
+var ErrHookClosed = errors.New("hook is closed")
+
+// AsyncOptions configures an AsyncHook
+type AsyncOptions struct {
+	// QueueSize is the maximum number of queued entries, 1024 when zero
+	QueueSize int
+	// Workers is the number of goroutines firing the wrapped hook, 1 when zero.
+	// With more than one worker entries may be delivered out of order.
+	Workers int
+	// Overflow is the policy applied when the queue is full
+	Overflow OverflowPolicy
+	// ErrorHandler receives a *HookError when the wrapped hook fails, errors
+	// are printed to os.Stderr when nil. Workers fire entries after the
+	// logger has returned, so these errors never reach Options.ErrorHandler.
+	ErrorHandler ErrorHandler
+}
+
+// asyncEntry is a queued entry together with the context of its event
+type asyncEntry struct {
+	ctx   context.Context
+	entry map[string]interface{}
+}
+
+// AsyncHook wraps a Hook so that entries are queued and fired by worker
+// goroutines, keeping slow hooks such as NatsHook off the logging path.
+type AsyncHook struct {
+	hook     Hook
+	queue    chan asyncEntry
+	overflow OverflowPolicy
+	onError  ErrorHandler
+	workers  sync.WaitGroup
+	dropped  atomic.Uint64
+
+	// mu guards closed, pending and idle; idle is closed whenever the queue
+	// is drained and all fired entries have been processed
+	mu      sync.Mutex
+	closed  bool
+	pending int
+	idle    chan struct{}
+}
+
+// NewAsyncHook wraps the hook and starts its workers
+func NewAsyncHook(hook Hook, opts AsyncOptions) *AsyncHook {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 1024
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+	if opts.ErrorHandler == nil {
+		opts.ErrorHandler = defaultErrorHandler
+	}
+
+	h := &AsyncHook{
+		hook:     hook,
+		queue:    make(chan asyncEntry, opts.QueueSize),
+		overflow: opts.Overflow,
+		onError:  opts.ErrorHandler,
+		idle:     make(chan struct{}),
+	}
+	close(h.idle)
+
+	for i := 0; i < opts.Workers; i++ {
+		h.workers.Add(1)
+		go h.work()
+	}
+	return h
+}
+
+// Fire queues the entry
+func (h *AsyncHook) Fire(entry map[string]interface{}) error {
+	return h.FireContext(context.Background(), entry)
+}
+
+// FireContext queues the entry together with the event's context, which is
+// passed on if the wrapped hook implements ContextHook
+func (h *AsyncHook) FireContext(ctx context.Context, entry map[string]interface{}) error {
+	item := asyncEntry{ctx: ctx, entry: entry}
+
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return ErrHookClosed
+	}
+	h.addPending(1)
+	h.mu.Unlock()
+
+	switch h.overflow {
+	case OverflowDropNewest:
+		select {
+		case h.queue <- item:
+		default:
+			h.drop()
+		}
+	case OverflowDropOldest:
+		for {
+			select {
+			case h.queue <- item:
+				return nil
+			default:
+			}
+			select {
+			case <-h.queue:
+				h.drop()
+			default:
+			}
+		}
+	default:
+		h.queue <- item
+	}
+	return nil
+}
+
+// Levels returns the levels of the wrapped hook
+func (h *AsyncHook) Levels() []Level {
+	return h.hook.Levels()
+}
+
+// Dropped returns the number of entries discarded because the queue was full
+func (h *AsyncHook) Dropped() uint64 {
+	return h.dropped.Load()
+}
+
+// Flush waits until every queued entry has been fired or ctx is done, then
+// flushes the wrapped hook if it buffers entries
+func (h *AsyncHook) Flush(ctx context.Context) error {
+	if err := h.wait(ctx); err != nil {
+		return err
+	}
+	return flush(ctx, h.hook)
+}
+
+// wait waits until every queued entry has been fired or ctx is done
+func (h *AsyncHook) wait(ctx context.Context) error {
+	h.mu.Lock()
+	idle := h.idle
+	h.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops accepting entries, fires the queued ones, stops the workers and
+// closes the wrapped hook if it implements io.Closer. It is safe to call
+// Close more than once.
+func (h *AsyncHook) Close() error {
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return nil
+	}
+	h.closed = true
+	h.mu.Unlock()
+
+	// Wait for producers already past the closed check before closing the queue
+	_ = h.wait(context.Background())
+	close(h.queue)
+	h.workers.Wait()
+	if c, ok := h.hook.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// work fires queued entries until the queue is closed
+func (h *AsyncHook) work() {
+	defer h.workers.Done()
+	for item := range h.queue {
+		var err error
+		if ch, ok := h.hook.(ContextHook); ok {
+			err = ch.FireContext(item.ctx, item.entry)
+		} else {
+			err = h.hook.Fire(item.entry)
+		}
+		if err != nil {
+			h.onError(&HookError{Hook: h.hook, Entry: item.entry, Err: err})
+		}
+		h.mu.Lock()
+		h.addPending(-1)
+		h.mu.Unlock()
+	}
+}
+
+// drop accounts for an entry discarded by the overflow policy
+func (h *AsyncHook) drop() {
+	h.dropped.Add(1)
+	h.mu.Lock()
+	h.addPending(-1)
+	h.mu.Unlock()
+}
+
+// addPending adjusts the pending count and the idle channel, h.mu must be held
+func (h *AsyncHook) addPending(delta int) {
+	if h.pending == 0 && delta > 0 {
+		h.idle = make(chan struct{})
+	}
+	h.pending += delta
+	if h.pending == 0 {
+		close(h.idle)
+	}
+}
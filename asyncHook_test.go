@@ -0,0 +1,326 @@
+package pdalog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// blockingHook blocks in Fire until it is released
+type blockingHook struct {
+	*MockHook
+	release chan struct{}
+}
+
+func newBlockingHook() *blockingHook {
+	return &blockingHook{MockHook: NewMockHook(), release: make(chan struct{})}
+}
+
+func (h *blockingHook) Fire(entry map[string]interface{}) error {
+	<-h.release
+	return h.MockHook.Fire(entry)
+}
+
+func (h *blockingHook) messages() []interface{} {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	messages := make([]interface{}, 0, len(h.FiredEntries))
+	for _, entry := range h.FiredEntries {
+		messages = append(messages, entry["message"])
+	}
+	return messages
+}
+
+// lifecycleHook records whether it was flushed and closed
+type lifecycleHook struct {
+	*MockHook
+	flushed atomic.Bool
+	closed  atomic.Bool
+}
+
+func (h *lifecycleHook) Flush(context.Context) error {
+	h.flushed.Store(true)
+	return nil
+}
+
+func (h *lifecycleHook) Close() error {
+	h.closed.Store(true)
+	return nil
+}
+
+// funcHook calls fire for every entry
+type funcHook struct {
+	levels []Level
+	fire   func()
+}
+
+func (h *funcHook) Fire(map[string]interface{}) error {
+	h.fire()
+	return nil
+}
+
+func (h *funcHook) Levels() []Level {
+	return h.levels
+}
+
+func TestSlowHookDoesNotBlockOtherEvents(t *testing.T) {
+	log := New(Options{Writer: &bytes.Buffer{}, Level: InfoLevel})
+	entered := make(chan struct{})
+	slow := &blockingHook{MockHook: NewMockHook(ErrorLevel), release: make(chan struct{})}
+	log.AddHook(&funcHook{levels: []Level{ErrorLevel}, fire: func() { close(entered) }})
+	log.AddHook(slow)
+
+	go log.Error().Msg("slow")
+	<-entered
+	// Wait for the event to reach the slow hook
+	time.Sleep(20 * time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		log.AddHook(NewMockHook(WarnLevel))
+		log.Info().Msg("not blocked")
+		log.RemoveHook(slow)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("AddHook and logging were blocked by a slow hook")
+	}
+	close(slow.release)
+}
+
+func TestHookCanAddHooks(t *testing.T) {
+	log := New(Options{Writer: &bytes.Buffer{}, Level: InfoLevel})
+	added := NewMockHook()
+	var once sync.Once
+	log.AddHook(&funcHook{levels: []Level{InfoLevel}, fire: func() {
+		once.Do(func() { log.AddHook(added) })
+	}})
+
+	done := make(chan struct{})
+	go func() {
+		log.Info().Msg("adds a hook")
+		log.Info().Msg("fires the added hook")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Adding a hook from a hook deadlocked")
+	}
+	if len(firedEntries(added)) == 0 {
+		t.Error("Expected the added hook to fire")
+	}
+}
+
+func TestAsyncHookDoesNotBlockLogging(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := New(Options{
+		Writer: buf,
+		Level:  DebugLevel,
+	})
+	inner := newBlockingHook()
+	hook := NewAsyncHook(inner, AsyncOptions{QueueSize: 10})
+	log.AddHook(hook)
+
+	done := make(chan struct{})
+	go func() {
+		log.Info().Msg("first")
+		log.Info().Msg("second")
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Logging blocked on a slow hook")
+	}
+
+	close(inner.release)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := hook.Flush(ctx); err != nil {
+		t.Fatalf("Flush returned error: %v", err)
+	}
+
+	messages := inner.messages()
+	if len(messages) != 2 || messages[0] != "first" || messages[1] != "second" {
+		t.Errorf("Expected entries to be fired in order, got %v", messages)
+	}
+}
+
+func TestAsyncHookOverflowPolicies(t *testing.T) {
+	tests := []struct {
+		policy   OverflowPolicy
+		expected []interface{}
+	}{
+		{OverflowDropNewest, []interface{}{"m0", "m1", "m2"}},
+		{OverflowDropOldest, []interface{}{"m0", "m3", "m4"}},
+	}
+
+	for _, test := range tests {
+		inner := newBlockingHook()
+		hook := NewAsyncHook(inner, AsyncOptions{QueueSize: 2, Overflow: test.policy})
+
+		// The worker takes m0 and blocks, the queue fills with the rest
+		_ = hook.Fire(map[string]interface{}{"message": "m0"})
+		waitForQueueLength(t, hook, 0)
+		for _, msg := range []string{"m1", "m2", "m3", "m4"} {
+			_ = hook.Fire(map[string]interface{}{"message": msg})
+		}
+
+		if hook.Dropped() != 2 {
+			t.Errorf("Policy %d: expected 2 dropped entries, got %d", test.policy, hook.Dropped())
+		}
+
+		close(inner.release)
+		if err := hook.Close(); err != nil {
+			t.Fatalf("Close returned error: %v", err)
+		}
+
+		messages := inner.messages()
+		if len(messages) != len(test.expected) {
+			t.Fatalf("Policy %d: expected %v, got %v", test.policy, test.expected, messages)
+		}
+		for i := range messages {
+			if messages[i] != test.expected[i] {
+				t.Errorf("Policy %d: expected %v, got %v", test.policy, test.expected, messages)
+				break
+			}
+		}
+	}
+}
+
+func TestAsyncHookBlockPolicy(t *testing.T) {
+	inner := newBlockingHook()
+	hook := NewAsyncHook(inner, AsyncOptions{QueueSize: 1, Overflow: OverflowBlock})
+
+	_ = hook.Fire(map[string]interface{}{"message": "m0"})
+	waitForQueueLength(t, hook, 0)
+	_ = hook.Fire(map[string]interface{}{"message": "m1"})
+
+	fired := make(chan struct{})
+	go func() {
+		_ = hook.Fire(map[string]interface{}{"message": "m2"})
+		close(fired)
+	}()
+
+	select {
+	case <-fired:
+		t.Fatal("Expected Fire to block while the queue is full")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(inner.release)
+	<-fired
+	if err := hook.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	if len(inner.messages()) != 3 || hook.Dropped() != 0 {
+		t.Errorf("Expected all 3 entries without drops, got %v and %d dropped", inner.messages(), hook.Dropped())
+	}
+}
+
+func TestAsyncHookFlushTimeout(t *testing.T) {
+	inner := newBlockingHook()
+	hook := NewAsyncHook(inner, AsyncOptions{})
+	_ = hook.Fire(map[string]interface{}{"message": "stuck"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := hook.Flush(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected Flush to time out, got %v", err)
+	}
+
+	close(inner.release)
+	if err := hook.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+}
+
+func TestAsyncHookClose(t *testing.T) {
+	inner := NewMockHook()
+	hook := NewAsyncHook(inner, AsyncOptions{Workers: 4})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 25; j++ {
+				_ = hook.Fire(map[string]interface{}{"message": "m"})
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := hook.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	if len(inner.FiredEntries) != 100 {
+		t.Errorf("Expected Close to drain all 100 entries, got %d", len(inner.FiredEntries))
+	}
+	if err := hook.Fire(map[string]interface{}{}); !errors.Is(err, ErrHookClosed) {
+		t.Errorf("Expected ErrHookClosed after Close, got %v", err)
+	}
+	if err := hook.Close(); err != nil {
+		t.Errorf("Expected a second Close to succeed, got %v", err)
+	}
+}
+
+func TestAsyncHookForwardsFlushAndClose(t *testing.T) {
+	inner := &lifecycleHook{MockHook: NewMockHook()}
+	exited := false
+	log := New(Options{Writer: &bytes.Buffer{}, ExitFunc: func(int) { exited = true }})
+	log.AddHook(NewAsyncHook(inner, AsyncOptions{}))
+
+	log.Fatal().Msg("fatal")
+	if !exited || !inner.flushed.Load() || inner.closed.Load() {
+		t.Errorf("Expected a Fatal event to flush the wrapped hook, flushed=%v closed=%v",
+			inner.flushed.Load(), inner.closed.Load())
+	}
+	if len(inner.FiredEntries) != 1 {
+		t.Errorf("Expected the fatal entry to be fired, got %d entries", len(inner.FiredEntries))
+	}
+
+	if err := log.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	if !inner.closed.Load() {
+		t.Error("Expected Close to close the wrapped hook")
+	}
+}
+
+func TestAsyncHookPassesContext(t *testing.T) {
+	inner := &mockContextHook{MockHook: NewMockHook()}
+	hook := NewAsyncHook(inner, AsyncOptions{})
+	log := New(Options{Writer: &bytes.Buffer{}})
+	log.AddHook(hook)
+
+	ctx := context.WithValue(context.Background(), testContextKey("k"), "v")
+	log.Info().Ctx(ctx).Msg("with context")
+	if err := hook.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	if len(inner.contexts) != 1 || inner.contexts[0] != ctx {
+		t.Error("Expected the wrapped hook to receive the event's context")
+	}
+}
+
+// waitForQueueLength waits until the worker has taken entries off the queue
+func waitForQueueLength(t *testing.T, hook *AsyncHook, length int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for len(hook.queue) != length {
+		if time.Now().After(deadline) {
+			t.Fatalf("Queue length did not reach %d", length)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
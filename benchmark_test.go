@@ -0,0 +1,122 @@
+package pdalog
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func newBenchmarkLogger() *Logger {
+	return New(Options{
+		Writer: io.Discard,
+		Level:  DebugLevel,
+	})
+}
+
+func BenchmarkLogEmpty(b *testing.B) {
+	log := newBenchmarkLogger()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		log.Info().Msg("")
+	}
+}
+
+func BenchmarkLogDisabled(b *testing.B) {
+	log := newBenchmarkLogger()
+	log.SetLevel(InfoLevel)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		log.Debug().Str("key", "value").Msg("disabled")
+	}
+}
+
+func BenchmarkLogFields(b *testing.B) {
+	log := newBenchmarkLogger()
+	err := errors.New("connection refused")
+	data := []byte{0xDE, 0xAD, 0xBE, 0xEF}
+	now := time.Now()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		log.Info().
+			Str("service", "api").
+			Int("status", 200).
+			Bool("cache_hit", true).
+			Err(err).
+			Duration("elapsed", 150*time.Millisecond).
+			Time("started", now).
+			Hex("signature", data).
+			Msg("request completed")
+	}
+}
+
+func BenchmarkLogContextFields(b *testing.B) {
+	log := newBenchmarkLogger().
+		With("request_id", "req-123456").
+		With("user_id", "user-789").
+		With("attempt", 3)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		log.Info().Str("path", "/users").Msg("request received")
+	}
+}
+
+func BenchmarkLogNamed(b *testing.B) {
+	log := newBenchmarkLogger()
+	_ = log.SetComponentLevels(map[string]Level{"db": DebugLevel, "http.*": WarnLevel, "*": InfoLevel})
+	db := log.Named("db").Named("pool")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		db.Debug().Str("query", "SELECT 1").Msg("query executed")
+	}
+}
+
+func BenchmarkLogOutputs(b *testing.B) {
+	log := New(Options{
+		Level: DebugLevel,
+		Outputs: []Output{
+			{Writer: io.Discard, Level: DebugLevel, Encoder: LogfmtEncoder{}},
+			{Writer: io.Discard, Level: InfoLevel},
+			{Writer: io.Discard, Level: ErrorLevel},
+		},
+	}).With("request_id", "req-123456")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		log.Info().Str("path", "/users").Int("status", 200).Msg("request received")
+	}
+}
+
+func BenchmarkLogParallel(b *testing.B) {
+	log := newBenchmarkLogger()
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			log.Info().Str("service", "api").Int("status", 200).Msg("request completed")
+		}
+	})
+}
+
+func BenchmarkWithChained(b *testing.B) {
+	log := newBenchmarkLogger()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = log.With("request_id", "req-123456").With("user_id", "user-789").With("attempt", 3)
+	}
+}
+
+func BenchmarkWithFields(b *testing.B) {
+	log := newBenchmarkLogger()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = log.WithFields().Str("request_id", "req-123456").Str("user_id", "user-789").Int("attempt", 3).Logger()
+	}
+}
@@ -0,0 +1,140 @@
+// Command pdalog-tail subscribes to log entries published by NatsHook and
+// renders them as human-readable lines:
+//
+//	pdalog-tail -server nats://localhost:4222 -subject 'logs.>' -level warn -filter component=db
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/nats-io/nats.go"
+	pdalog "github.com/pdat-cz/go-pda-log"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		_, _ = fmt.Fprintf(os.Stderr, "pdalog-tail: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run parses the arguments, subscribes and renders entries until ctx is done
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("pdalog-tail", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	server := fs.String("server", nats.DefaultURL, "NATS server URL")
+	subject := fs.String("subject", "logs.>", "subject pattern to subscribe to")
+	noColor := fs.Bool("no-color", false, "disable colors")
+	timeFormat := fs.String("time-format", pdalog.DefaultConsoleTimeFormat, "layout the time is rendered with")
+	minLevel := pdalog.TraceLevel
+	fs.Var(&minLevel, "level", "minimum level to show")
+	var filters filterList
+	fs.Var(&filters, "filter", "show only entries matching key=value, key!=value or key~substring; may be repeated")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	t := newTailer(stdout, minLevel, filters)
+	t.console.TimeFormat = *timeFormat
+	if *noColor {
+		t.console.NoColor = true
+	}
+
+	nc, err := nats.Connect(*server, nats.Name("pdalog-tail"), nats.MaxReconnects(-1))
+	if err != nil {
+		return err
+	}
+	defer nc.Close()
+
+	sub, err := t.subscribe(nc, *subject)
+	if err != nil {
+		return err
+	}
+	defer sub.Unsubscribe()
+
+	<-ctx.Done()
+	return nil
+}
+
+// filter is a condition on an entry field
+type filter struct {
+	key   string
+	op    string
+	value string
+}
+
+// filterList collects the -filter flags
+type filterList []filter
+
+// String implements flag.Value
+func (f *filterList) String() string {
+	parts := make([]string, len(*f))
+	for i, flt := range *f {
+		parts[i] = flt.key + flt.op + flt.value
+	}
+	return strings.Join(parts, ",")
+}
+
+// Set implements flag.Value
+func (f *filterList) Set(expr string) error {
+	flt, err := parseFilter(expr)
+	if err != nil {
+		return err
+	}
+	*f = append(*f, flt)
+	return nil
+}
+
+// parseFilter parses key=value, key!=value and key~substring, splitting at
+// the first operator so values may contain the others
+func parseFilter(expr string) (filter, error) {
+	for i := 0; i < len(expr); i++ {
+		op := ""
+		switch {
+		case strings.HasPrefix(expr[i:], "!="):
+			op = "!="
+		case expr[i] == '~', expr[i] == '=':
+			op = expr[i : i+1]
+		default:
+			continue
+		}
+		key := strings.TrimSpace(expr[:i])
+		if key == "" {
+			break
+		}
+		return filter{key: key, op: op, value: expr[i+len(op):]}, nil
+	}
+	return filter{}, fmt.Errorf("invalid filter %q, expected key=value, key!=value or key~substring", expr)
+}
+
+// matches reports whether the entry satisfies the filter. Missing fields
+// only match != filters.
+func (f filter) matches(entry map[string]interface{}) bool {
+	val, ok := entry[f.key]
+	text := ""
+	if ok {
+		text = fmt.Sprint(val)
+	}
+	switch f.op {
+	case "!=":
+		return !ok || text != f.value
+	case "~":
+		return ok && strings.Contains(text, f.value)
+	default:
+		return ok && text == f.value
+	}
+}
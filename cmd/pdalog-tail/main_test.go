@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	pdalog "github.com/pdat-cz/go-pda-log"
+)
+
+// syncBuffer is a bytes.Buffer safe for the subscription goroutine
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// startServer runs an embedded NATS server
+func startServer(t *testing.T) *server.Server {
+	t.Helper()
+	srv, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: -1, NoLog: true, NoSigs: true})
+	if err != nil {
+		t.Fatalf("NewServer returned error: %v", err)
+	}
+	srv.Start()
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatal("NATS server did not start")
+	}
+	t.Cleanup(srv.Shutdown)
+	return srv
+}
+
+// waitFor polls until cond is true
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestRun(t *testing.T) {
+	srv := startServer(t)
+	systemSubs := srv.NumSubscriptions()
+	out := &syncBuffer{}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- run(ctx, []string{
+			"-server", srv.ClientURL(),
+			"-subject", "logs.>",
+			"-level", "warn",
+			"-filter", "component=db",
+			"-no-color",
+			"-time-format", time.RFC3339,
+		}, out, &bytes.Buffer{})
+	}()
+	waitFor(t, "the subscription", func() bool { return srv.NumSubscriptions() > systemSubs })
+
+	nc, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatalf("Connect returned error: %v", err)
+	}
+	defer nc.Close()
+
+	log := pdalog.New(pdalog.Options{Writer: &bytes.Buffer{}, Level: pdalog.DebugLevel})
+	log.AddHook(pdalog.NewNatsHook(nc, "logs.{level}").WithHeaders("component"))
+	log.Info().Str("component", "db").Msg("below the level")
+	log.Warn().Str("component", "http").Msg("other component")
+	log.Error().Str("component", "db").Int("attempt", 3).Msg("connection lost")
+	if err := nc.Publish("logs.raw", []byte("plain text")); err != nil {
+		t.Fatalf("Publish returned error: %v", err)
+	}
+	if err := nc.Flush(); err != nil {
+		t.Fatalf("Flush returned error: %v", err)
+	}
+
+	waitFor(t, "the rendered lines", func() bool { return strings.Count(out.String(), "\n") >= 2 })
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("run returned error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %q", out.String())
+	}
+	if !strings.Contains(lines[0], " ERR connection lost attempt=3 component=db") {
+		t.Errorf("Unexpected entry line %q", lines[0])
+	}
+	if lines[1] != "plain text" {
+		t.Errorf("Expected non-JSON payloads to be printed as they are, got %q", lines[1])
+	}
+}
+
+func TestRunInvalidFlags(t *testing.T) {
+	stderr := &bytes.Buffer{}
+	if err := run(context.Background(), []string{"-filter", "novalue"}, &bytes.Buffer{}, stderr); err == nil {
+		t.Error("Expected an error for an invalid filter")
+	}
+	if err := run(context.Background(), []string{"-level", "loud"}, &bytes.Buffer{}, stderr); err == nil {
+		t.Error("Expected an error for an unknown level")
+	}
+}
+
+func TestFilterMatches(t *testing.T) {
+	entry := map[string]interface{}{"component": "db.pool", "status": float64(503), "cached": false}
+
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{"component=db.pool", true},
+		{"component=db", false},
+		{"component~pool", true},
+		{"component!=http", true},
+		{"status=503", true},
+		{"status!=503", false},
+		{"cached=false", true},
+		{"missing=x", false},
+		{"missing!=x", true},
+		{"missing~x", false},
+		{"component=db.p~ol", false},
+		{"component!=db=pool", true},
+	}
+
+	for _, tt := range tests {
+		f, err := parseFilter(tt.expr)
+		if err != nil {
+			t.Fatalf("parseFilter(%q) returned error: %v", tt.expr, err)
+		}
+		if got := f.matches(entry); got != tt.expected {
+			t.Errorf("%q matches = %v, expected %v", tt.expr, got, tt.expected)
+		}
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected filter
+	}{
+		{"path=/~user", filter{key: "path", op: "=", value: "/~user"}},
+		{"path~a=b", filter{key: "path", op: "~", value: "a=b"}},
+		{"path!=a~b", filter{key: "path", op: "!=", value: "a~b"}},
+		{" level = ", filter{key: "level", op: "=", value: " "}},
+	}
+	for _, tt := range tests {
+		f, err := parseFilter(tt.expr)
+		if err != nil {
+			t.Fatalf("parseFilter(%q) returned error: %v", tt.expr, err)
+		}
+		if f != tt.expected {
+			t.Errorf("parseFilter(%q) = %+v, expected %+v", tt.expr, f, tt.expected)
+		}
+	}
+
+	for _, expr := range []string{"", "path", "=x", "~x"} {
+		if _, err := parseFilter(expr); err == nil {
+			t.Errorf("parseFilter(%q) returned no error", expr)
+		}
+	}
+}
+
+func TestHandleNullPayloadWithHeaders(t *testing.T) {
+	out := &bytes.Buffer{}
+	tl := newTailer(out, pdalog.TraceLevel, nil)
+
+	msg := nats.NewMsg("logs.app")
+	msg.Data = []byte("null")
+	msg.Header.Set("component", "db")
+	tl.handle(msg)
+
+	if out.String() != "null\n" {
+		t.Errorf("Expected the payload to be printed as is, got %q", out.String())
+	}
+}
+
+func TestHandleKeepsFieldOrder(t *testing.T) {
+	out := &bytes.Buffer{}
+	tl := newTailer(out, pdalog.TraceLevel, nil)
+	tl.console.NoColor = true
+
+	msg := nats.NewMsg("logs.app")
+	msg.Data = []byte(`{"level":"info","message":"m","zeta":1,"alpha":"a"}`)
+	tl.handle(msg)
+
+	msg.Header.Set("component", "db")
+	msg.Header.Set("Nats-Msg-Id", "id-1")
+	tl.handle(msg)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || !strings.HasSuffix(lines[0], "INF m zeta=1 alpha=a") {
+		t.Fatalf("Expected the payload order to be kept, got %q", out.String())
+	}
+	if !strings.HasSuffix(lines[1], "INF m zeta=1 alpha=a component=db") {
+		t.Errorf("Expected the header fields after the payload fields, got %q", lines[1])
+	}
+}
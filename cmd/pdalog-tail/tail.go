@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+	pdalog "github.com/pdat-cz/go-pda-log"
+)
+
+// tailer filters received log entries and renders them with a ConsoleWriter
+type tailer struct {
+	console  *pdalog.ConsoleWriter
+	minLevel pdalog.Level
+	filters  []filter
+}
+
+// newTailer creates a tailer writing to out
+func newTailer(out io.Writer, minLevel pdalog.Level, filters []filter) *tailer {
+	return &tailer{
+		console:  pdalog.NewConsoleWriter(out),
+		minLevel: minLevel,
+		filters:  filters,
+	}
+}
+
+// subscribe renders the entries published to subject until the subscription ends
+func (t *tailer) subscribe(nc *nats.Conn, subject string) (*nats.Subscription, error) {
+	sub, err := nc.Subscribe(subject, t.handle)
+	if err != nil {
+		return nil, err
+	}
+	// Make sure the server has registered the subscription before returning
+	if err := nc.Flush(); err != nil {
+		_ = sub.Unsubscribe()
+		return nil, err
+	}
+	return sub, nil
+}
+
+// handle renders a message if it passes the filters. Fields that NatsHook
+// sent as headers are shown after the payload fields, which keep the order
+// they were logged in; payloads that are not JSON objects are printed as
+// they are.
+func (t *tailer) handle(msg *nats.Msg) {
+	var entry map[string]interface{}
+	if err := json.Unmarshal(msg.Data, &entry); err != nil || entry == nil {
+		_, _ = t.console.Write(append(bytes.TrimRight(msg.Data, "\n"), '\n'))
+		return
+	}
+	var headers []string
+	for key := range msg.Header {
+		// Headers set by NATS itself, such as Nats-Msg-Id, are not log fields
+		if _, ok := entry[key]; !ok && !strings.HasPrefix(key, "Nats-") {
+			entry[key] = msg.Header.Get(key)
+			headers = append(headers, key)
+		}
+	}
+
+	if !t.accepts(entry) {
+		return
+	}
+
+	// The payload is passed on as it is, so the fields are not reordered
+	var line bytes.Buffer
+	if err := json.Compact(&line, msg.Data); err != nil {
+		return
+	}
+	if len(headers) > 0 {
+		sort.Strings(headers)
+		line.Truncate(line.Len() - 1)
+		for i, key := range headers {
+			if i > 0 || len(entry) > len(headers) {
+				line.WriteByte(',')
+			}
+			k, _ := json.Marshal(key)
+			v, _ := json.Marshal(entry[key])
+			line.Write(k)
+			line.WriteByte(':')
+			line.Write(v)
+		}
+		line.WriteByte('}')
+	}
+	line.WriteByte('\n')
+	_, _ = t.console.Write(line.Bytes())
+}
+
+// accepts reports whether the entry's level and fields pass the filters.
+// Entries with an unknown level are shown.
+func (t *tailer) accepts(entry map[string]interface{}) bool {
+	if name, ok := entry["level"].(string); ok {
+		if level, err := pdalog.ParseLevelStrict(name); err == nil && level < t.minLevel {
+			return false
+		}
+	}
+	for _, f := range t.filters {
+		if !f.matches(entry) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,223 @@
+package pdalog
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// componentField is the field holding the name of a named logger
+const componentField = "component"
+
+// componentLevels is an immutable table of component level patterns, replaced
+// as a whole when the levels change
+type componentLevels struct {
+	levels map[string]Level
+	// patterns are ordered most specific first
+	patterns []componentPattern
+}
+
+// componentPattern is a compiled entry of componentLevels
+type componentPattern struct {
+	pattern string
+	level   Level
+	glob    bool
+}
+
+// componentLevelCache is a named logger's level resolved against a table
+type componentLevelCache struct {
+	table *componentLevels
+	level Level
+	ok    bool
+}
+
+// componentState holds the component levels shared by a root logger and its children
+type componentState struct {
+	// mu serializes changes to table
+	mu    sync.Mutex
+	table atomic.Pointer[componentLevels]
+}
+
+// Named returns a child logger for a component of the application. Events of
+// the child carry the name in the component field, and its level can be
+// configured by name with SetComponentLevels. Names of nested named loggers
+// are joined with dots, e.g. log.Named("http").Named("client") is named
+// "http.client". An empty name keeps the name of l.
+func (l *Logger) Named(name string) *Logger {
+	child := l.WithFields().Logger()
+	if name == "" {
+		return child
+	}
+	if l.name != "" {
+		name = l.name + "." + name
+	}
+
+	child.name = name
+	child.contextFields[componentField] = name
+	child.nameField = make([][]byte, len(l.core.encoders))
+	for i, enc := range l.core.encoders {
+		child.nameField[i] = enc.AppendString(enc.AppendKey(nil, componentField), name)
+	}
+	return child
+}
+
+// Name returns the name of a logger created with Named, empty otherwise
+func (l *Logger) Name() string {
+	return l.name
+}
+
+// SetComponentLevels replaces the levels of named loggers, see
+// ParseComponentLevels for the patterns. The levels are shared by the root
+// logger and all of its children and can be changed at any time, e.g. to
+// enable debug logging for one component of a running process.
+//
+// A named logger uses the level of the most specific matching pattern: a
+// pattern without wildcards such as "db" matches the name and its
+// descendants such as "db.pool", while patterns such as "http.*" are matched
+// with path.Match; longer patterns take precedence. Loggers that match no
+// pattern follow the root logger's level, and a level set with SetLevel on a
+// child logger overrides the patterns for the child and its descendants.
+func (l *Logger) SetComponentLevels(levels map[string]Level) error {
+	table, err := compileComponentLevels(levels)
+	if err != nil {
+		return err
+	}
+	state := &l.core.components
+	state.mu.Lock()
+	state.table.Store(table)
+	state.mu.Unlock()
+	return nil
+}
+
+// SetComponentLevel sets the level of a single component pattern, keeping
+// the other patterns
+func (l *Logger) SetComponentLevel(pattern string, level Level) error {
+	state := &l.core.components
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	levels := l.ComponentLevels()
+	levels[pattern] = level
+	table, err := compileComponentLevels(levels)
+	if err != nil {
+		return err
+	}
+	state.table.Store(table)
+	return nil
+}
+
+// RemoveComponentLevel drops a component pattern, so the named loggers it
+// matched fall back to other patterns or the root logger's level
+func (l *Logger) RemoveComponentLevel(pattern string) {
+	state := &l.core.components
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	levels := l.ComponentLevels()
+	if _, ok := levels[pattern]; !ok {
+		return
+	}
+	delete(levels, pattern)
+	// The remaining patterns were already validated
+	table, _ := compileComponentLevels(levels)
+	state.table.Store(table)
+}
+
+// ComponentLevels returns a copy of the component level patterns
+func (l *Logger) ComponentLevels() map[string]Level {
+	levels := make(map[string]Level)
+	if table := l.core.components.table.Load(); table != nil {
+		for pattern, level := range table.levels {
+			levels[pattern] = level
+		}
+	}
+	return levels
+}
+
+// ParseComponentLevels parses component levels such as
+// "db=debug,http.*=warn,*=info" for SetComponentLevels. Entries are separated
+// by commas, levels are parsed with ParseLevelStrict.
+func ParseComponentLevels(spec string) (map[string]Level, error) {
+	levels := make(map[string]Level)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		pattern, levelStr, ok := strings.Cut(entry, "=")
+		pattern = strings.TrimSpace(pattern)
+		if !ok || pattern == "" {
+			return nil, fmt.Errorf("invalid component level %q, expected pattern=level", entry)
+		}
+		level, err := ParseLevelStrict(levelStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid component level %q: %w", entry, err)
+		}
+		levels[pattern] = level
+	}
+	return levels, nil
+}
+
+// compileComponentLevels validates the patterns and orders them by specificity
+func compileComponentLevels(levels map[string]Level) (*componentLevels, error) {
+	table := &componentLevels{levels: make(map[string]Level, len(levels))}
+	for pattern, level := range levels {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid component pattern %q: %w", pattern, err)
+		}
+		table.levels[pattern] = level
+		table.patterns = append(table.patterns, componentPattern{
+			pattern: pattern,
+			level:   level,
+			glob:    strings.ContainsAny(pattern, `*?[\`),
+		})
+	}
+
+	sort.Slice(table.patterns, func(i, j int) bool {
+		a, b := table.patterns[i], table.patterns[j]
+		if len(a.pattern) != len(b.pattern) {
+			return len(a.pattern) > len(b.pattern)
+		}
+		if a.glob != b.glob {
+			return !a.glob
+		}
+		return a.pattern < b.pattern
+	})
+	return table, nil
+}
+
+// match returns the level of the most specific pattern matching the name
+func (t *componentLevels) match(name string) (Level, bool) {
+	for _, p := range t.patterns {
+		if p.glob {
+			if ok, _ := path.Match(p.pattern, name); ok {
+				return p.level, true
+			}
+		} else if name == p.pattern || strings.HasPrefix(name, p.pattern+".") {
+			return p.level, true
+		}
+	}
+	return 0, false
+}
+
+// componentLevel returns the level configured for the logger's name. The
+// result is cached until the component levels change, so events of named
+// loggers do not match patterns.
+func (l *Logger) componentLevel() (Level, bool) {
+	if l.name == "" {
+		return 0, false
+	}
+	table := l.core.components.table.Load()
+	if table == nil {
+		return 0, false
+	}
+	if cache := l.componentCache.Load(); cache != nil && cache.table == table {
+		return cache.level, cache.ok
+	}
+	level, ok := table.match(l.name)
+	l.componentCache.Store(&componentLevelCache{table: table, level: level, ok: ok})
+	return level, ok
+}
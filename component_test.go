@@ -0,0 +1,138 @@
+package pdalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNamedLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := New(Options{Writer: buf, Level: InfoLevel})
+	hook := NewMockHook()
+	log.AddHook(hook)
+
+	client := log.With("request_id", "r1").Named("http").Named("client")
+	if client.Name() != "http.client" {
+		t.Errorf("Expected name http.client, got %q", client.Name())
+	}
+	client.Info().Str("path", "/users").Msg("request")
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Failed to parse log line %q: %v", buf.String(), err)
+	}
+	if entry["component"] != "http.client" || entry["request_id"] != "r1" || entry["path"] != "/users" {
+		t.Errorf("Unexpected entry %v", entry)
+	}
+	if strings.Count(buf.String(), `"component"`) != 1 {
+		t.Errorf("Expected a single component field, got %s", buf.String())
+	}
+	if !strings.Contains(buf.String(), `"message":"request","component":"http.client","request_id":"r1"`) {
+		t.Errorf("Expected the component to lead the context fields, got %s", buf.String())
+	}
+	if fired := firedEntries(hook); len(fired) != 1 || fired[0]["component"] != "http.client" {
+		t.Errorf("Expected the hook to receive the component, got %v", fired)
+	}
+
+	if child := client.Named(""); child.Name() != "http.client" {
+		t.Errorf("Expected an empty name to keep the parent's name, got %q", child.Name())
+	}
+}
+
+func TestNamedLoggerLogfmt(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := New(Options{Writer: buf, Encoder: LogfmtEncoder{}})
+
+	log.Named("db").With("shard", 2).Info().Msg("connected")
+	if !strings.Contains(buf.String(), "message=connected component=db shard=2\n") {
+		t.Errorf("Unexpected line %q", buf.String())
+	}
+}
+
+func TestComponentLevels(t *testing.T) {
+	buf := &bytes.Buffer{}
+	levels, err := ParseComponentLevels("db=debug, http.*=warn ,*=info")
+	if err != nil {
+		t.Fatalf("ParseComponentLevels returned error: %v", err)
+	}
+	log := New(Options{Writer: buf, Level: ErrorLevel, ComponentLevels: levels})
+
+	tests := []struct {
+		logger   *Logger
+		expected Level
+	}{
+		{log, ErrorLevel},
+		{log.Named("db"), DebugLevel},
+		{log.Named("db").Named("pool"), DebugLevel},
+		{log.Named("dbx"), InfoLevel},
+		{log.Named("http"), InfoLevel},
+		{log.Named("http").Named("client"), WarnLevel},
+		{log.Named("cache"), InfoLevel},
+		{log.Named("db").With("shard", 1), DebugLevel},
+	}
+	for _, test := range tests {
+		if got := test.logger.GetLevel(); got != test.expected {
+			t.Errorf("GetLevel() of %q = %v, want %v", test.logger.Name(), got, test.expected)
+		}
+	}
+
+	db := log.Named("db")
+	db.Debug().Msg("query")
+	log.Info().Msg("root info")
+	if countLines(buf, `"message":"query"`) != 1 || countLines(buf, "root info") != 0 {
+		t.Errorf("Unexpected output %s", buf.String())
+	}
+}
+
+func TestComponentLevelsAtRuntime(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := New(Options{Writer: buf, Level: InfoLevel})
+	db := log.Named("db")
+
+	db.Debug().Msg("before")
+	if err := log.SetComponentLevel("db", DebugLevel); err != nil {
+		t.Fatalf("SetComponentLevel returned error: %v", err)
+	}
+	db.Debug().Msg("enabled")
+	if err := db.SetComponentLevel("http.*", WarnLevel); err != nil {
+		t.Fatalf("SetComponentLevel returned error: %v", err)
+	}
+	if levels := log.ComponentLevels(); len(levels) != 2 || levels["db"] != DebugLevel || levels["http.*"] != WarnLevel {
+		t.Errorf("Unexpected component levels %v", levels)
+	}
+
+	log.RemoveComponentLevel("db")
+	db.Debug().Msg("disabled")
+
+	if countLines(buf, "before") != 0 || countLines(buf, "enabled") != 1 || countLines(buf, "disabled") != 0 {
+		t.Errorf("Unexpected output %s", buf.String())
+	}
+
+	// A level set on a child overrides the patterns
+	if err := log.SetComponentLevels(map[string]Level{"*": ErrorLevel}); err != nil {
+		t.Fatalf("SetComponentLevels returned error: %v", err)
+	}
+	db.SetLevel(TraceLevel)
+	if db.Named("pool").GetLevel() != TraceLevel {
+		t.Errorf("Expected the child's level to take precedence, got %v", db.Named("pool").GetLevel())
+	}
+}
+
+func TestParseComponentLevelsErrors(t *testing.T) {
+	for _, spec := range []string{"db", "=debug", "db=verbose"} {
+		if _, err := ParseComponentLevels(spec); err == nil {
+			t.Errorf("ParseComponentLevels(%q) returned no error", spec)
+		}
+	}
+	if _, err := ParseComponentLevels("db=verbose"); !errors.Is(err, ErrUnknownLevel) {
+		t.Errorf("Expected ErrUnknownLevel, got %v", err)
+	}
+
+	log := New(Options{Writer: &bytes.Buffer{}})
+	if err := log.SetComponentLevels(map[string]Level{"db[": DebugLevel}); err == nil {
+		t.Error("Expected an error for an invalid pattern")
+	}
+}
@@ -0,0 +1,297 @@
+package pdalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ANSI escape sequences used by ConsoleWriter
+const (
+	colorReset    = "\x1b[0m"
+	colorBold     = "\x1b[1m"
+	colorRed      = "\x1b[31m"
+	colorGreen    = "\x1b[32m"
+	colorYellow   = "\x1b[33m"
+	colorBlue     = "\x1b[34m"
+	colorMagenta  = "\x1b[35m"
+	colorCyan     = "\x1b[36m"
+	colorDarkGray = "\x1b[90m"
+)
+
+// DefaultConsoleTimeFormat is the time layout used by ConsoleWriter when none is set
+const DefaultConsoleTimeFormat = "15:04:05"
+
+// ConsoleWriter parses JSON log lines and writes them to Out in a
+// human-readable form such as "15:04:05 INF message key=value".
+// It is meant for local development and is usable as Options.Writer.
+type ConsoleWriter struct {
+	// Out is the destination of the rendered lines
+	Out io.Writer
+	// NoColor disables ANSI colors
+	NoColor bool
+	// TimeFormat is the layout the time field is rendered with
+	TimeFormat string
+	// FieldsOrder lists fields rendered first, in the given order; remaining
+	// fields follow in the order they appear in the log line
+	FieldsOrder []string
+	// FieldsExclude lists fields that are not rendered at all
+	FieldsExclude []string
+}
+
+// NewConsoleWriter creates a ConsoleWriter writing to out. Colors are enabled
+// only when out is a terminal and the NO_COLOR environment variable is unset or
+// empty.
+func NewConsoleWriter(out io.Writer) *ConsoleWriter {
+	if out == nil {
+		out = os.Stdout
+	}
+	return &ConsoleWriter{
+		Out:        out,
+		NoColor:    !colorSupported(out),
+		TimeFormat: DefaultConsoleTimeFormat,
+	}
+}
+
+// colorSupported reports whether colored output should be written to w
+func colorSupported(w io.Writer) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+// errNotObject is returned when a console line is not a JSON object
+var errNotObject = errors.New("log line is not a JSON object")
+
+// consoleField is a single key/value pair of a parsed log line
+type consoleField struct {
+	key   string
+	value json.RawMessage
+}
+
+// Write renders every JSON line in p. Lines that are not JSON objects are
+// passed through unchanged.
+func (w *ConsoleWriter) Write(p []byte) (int, error) {
+	var out bytes.Buffer
+	for _, line := range bytes.SplitAfter(p, []byte{'\n'}) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		fields, err := parseConsoleFields(line)
+		if err != nil {
+			out.Write(line)
+			continue
+		}
+		w.render(&out, fields)
+	}
+	if _, err := w.Out.Write(out.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// parseConsoleFields decodes a JSON object while preserving the field order
+func parseConsoleFields(line []byte) ([]consoleField, error) {
+	dec := json.NewDecoder(bytes.NewReader(line))
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return nil, errNotObject
+	}
+
+	var fields []consoleField
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := tok.(string)
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		fields = append(fields, consoleField{key: key, value: value})
+	}
+	return fields, nil
+}
+
+// render writes one parsed log line to out
+func (w *ConsoleWriter) render(out *bytes.Buffer, fields []consoleField) {
+	var timeVal, levelVal, messageVal string
+	rest := make([]consoleField, 0, len(fields))
+	for _, f := range fields {
+		switch f.key {
+		case "time":
+			timeVal = rawString(f.value)
+		case "level":
+			levelVal = rawString(f.value)
+		case "message":
+			messageVal = rawString(f.value)
+		default:
+			if !w.excluded(f.key) {
+				rest = append(rest, f)
+			}
+		}
+	}
+
+	if !w.excluded("time") {
+		out.WriteString(w.colorize(w.formatTime(timeVal), colorDarkGray))
+		out.WriteByte(' ')
+	}
+	if !w.excluded("level") {
+		out.WriteString(w.colorize(levelAbbreviation(levelVal), levelColor(levelVal)))
+		out.WriteByte(' ')
+	}
+	if !w.excluded("message") {
+		out.WriteString(messageVal)
+	}
+
+	for _, f := range w.ordered(rest) {
+		out.WriteByte(' ')
+		out.WriteString(w.colorize(f.key+"=", colorCyan))
+		value := formatConsoleValue(f.value)
+		if f.key == "error" {
+			value = w.colorize(value, colorRed)
+		}
+		out.WriteString(value)
+	}
+	out.WriteByte('\n')
+}
+
+// ordered moves the fields listed in FieldsOrder to the front
+func (w *ConsoleWriter) ordered(fields []consoleField) []consoleField {
+	if len(w.FieldsOrder) == 0 {
+		return fields
+	}
+	result := make([]consoleField, 0, len(fields))
+	used := make([]bool, len(fields))
+	for _, key := range w.FieldsOrder {
+		for i, f := range fields {
+			if !used[i] && f.key == key {
+				result = append(result, f)
+				used[i] = true
+			}
+		}
+	}
+	for i, f := range fields {
+		if !used[i] {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+// excluded reports whether the field is listed in FieldsExclude
+func (w *ConsoleWriter) excluded(key string) bool {
+	for _, k := range w.FieldsExclude {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// colorize wraps s in the given color unless colors are disabled
+func (w *ConsoleWriter) colorize(s, color string) string {
+	if w.NoColor || color == "" {
+		return s
+	}
+	return color + s + colorReset
+}
+
+// formatTime renders the time field using TimeFormat
+func (w *ConsoleWriter) formatTime(value string) string {
+	format := w.TimeFormat
+	if format == "" {
+		format = DefaultConsoleTimeFormat
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return value
+	}
+	return t.Format(format)
+}
+
+// levelAbbreviation returns the three letter form of a level name
+func levelAbbreviation(level string) string {
+	switch level {
+	case "trace":
+		return "TRC"
+	case "debug":
+		return "DBG"
+	case "info":
+		return "INF"
+	case "warn":
+		return "WRN"
+	case "error":
+		return "ERR"
+	case "fatal":
+		return "FTL"
+	case "panic":
+		return "PNC"
+	case "":
+		return "???"
+	}
+	upper := strings.ToUpper(level)
+	if len(upper) > 3 {
+		upper = upper[:3]
+	}
+	return upper
+}
+
+// levelColor returns the color a level is rendered with
+func levelColor(level string) string {
+	switch level {
+	case "trace":
+		return colorBlue
+	case "debug":
+		return colorMagenta
+	case "info":
+		return colorGreen
+	case "warn":
+		return colorYellow
+	case "error":
+		return colorRed
+	case "fatal", "panic":
+		return colorBold + colorRed
+	}
+	return colorCyan
+}
+
+// rawString returns the value of a JSON string, or the raw JSON otherwise
+func rawString(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	return string(raw)
+}
+
+// formatConsoleValue renders a JSON value for key=value output, quoting
+// strings only when they contain spaces or special characters
+func formatConsoleValue(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return string(raw)
+	}
+	if s == "" || strings.ContainsAny(s, " \t\n\r\"=") || !strconv.CanBackquote(s) {
+		return strconv.Quote(s)
+	}
+	return s
+}
@@ -0,0 +1,109 @@
+package pdalog
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestConsoleWriter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := &ConsoleWriter{Out: buf, NoColor: true}
+
+	line := `{"time":"2025-08-04T21:02:00Z","level":"info","message":"request done","path":"/users","status":200,"note":"two words"}` + "\n"
+	n, err := w.Write([]byte(line))
+	if err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	if n != len(line) {
+		t.Errorf("Expected Write to report %d bytes, got %d", len(line), n)
+	}
+
+	expected := `21:02:00 INF request done path=/users status=200 note="two words"` + "\n"
+	if buf.String() != expected {
+		t.Errorf("Unexpected console output\n got: %q\nwant: %q", buf.String(), expected)
+	}
+}
+
+func TestConsoleWriterFieldsOrderAndExclude(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := &ConsoleWriter{
+		Out:           buf,
+		NoColor:       true,
+		FieldsOrder:   []string{"component", "error"},
+		FieldsExclude: []string{"time", "pid"},
+	}
+
+	line := `{"time":"2025-08-04T21:02:00Z","level":"error","message":"failed","pid":42,"error":"boom","component":"db"}`
+	if _, err := w.Write([]byte(line)); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+
+	expected := "ERR failed component=db error=boom\n"
+	if buf.String() != expected {
+		t.Errorf("Unexpected console output\n got: %q\nwant: %q", buf.String(), expected)
+	}
+}
+
+func TestConsoleWriterColors(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := &ConsoleWriter{Out: buf}
+
+	line := `{"time":"2025-08-04T21:02:00Z","level":"warn","message":"slow"}`
+	if _, err := w.Write([]byte(line)); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+
+	if !strings.Contains(buf.String(), colorYellow+"WRN"+colorReset) {
+		t.Errorf("Expected colored level in output, got %q", buf.String())
+	}
+}
+
+func TestConsoleWriterNoColorEnv(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	if w := NewConsoleWriter(&bytes.Buffer{}); !w.NoColor {
+		t.Error("Expected NO_COLOR to disable colors")
+	}
+
+	// The null device is a character device like a terminal
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Skipf("Cannot open %s: %v", os.DevNull, err)
+	}
+	defer devNull.Close()
+	if fi, err := devNull.Stat(); err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		t.Skipf("%s is not a character device", os.DevNull)
+	}
+	t.Setenv("NO_COLOR", "")
+	if !colorSupported(devNull) {
+		t.Error("Expected an empty NO_COLOR to keep colors")
+	}
+}
+
+func TestConsoleWriterPassThrough(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := &ConsoleWriter{Out: buf, NoColor: true}
+
+	if _, err := w.Write([]byte("not json\n")); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	if buf.String() != "not json\n" {
+		t.Errorf("Expected non-JSON input to pass through, got %q", buf.String())
+	}
+}
+
+func TestConsoleWriterWithLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := New(Options{
+		Writer: &ConsoleWriter{Out: buf, NoColor: true, FieldsExclude: []string{"time"}},
+		Level:  DebugLevel,
+	})
+
+	log.With("service", "api").Debug().Int("port", 8080).Msg("listening")
+
+	expected := "DBG listening service=api port=8080\n"
+	if buf.String() != expected {
+		t.Errorf("Unexpected console output\n got: %q\nwant: %q", buf.String(), expected)
+	}
+}
@@ -0,0 +1,78 @@
+package pdalog
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// ContextExtractor adds fields taken from a context.Context to an event.
+// Extractors are configured with Options.ContextExtractors and run by Event.Ctx.
+type ContextExtractor func(ctx context.Context, e *Event)
+
+// loggerContextKey is the context key WithContext stores the logger under
+type loggerContextKey struct{}
+
+// defaultLogger is returned by FromContext when the context carries no logger
+var defaultLogger atomic.Pointer[Logger]
+
+// WithContext returns a copy of ctx carrying the logger
+func WithContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, l)
+}
+
+// FromContext returns the logger stored in ctx by WithContext, or the
+// default logger when there is none
+func FromContext(ctx context.Context) *Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(loggerContextKey{}).(*Logger); ok && l != nil {
+			return l
+		}
+	}
+	return Default()
+}
+
+// Default returns the fallback logger used by FromContext. Unless replaced
+// with SetDefault, it is a console logger at info level.
+func Default() *Logger {
+	if l := defaultLogger.Load(); l != nil {
+		return l
+	}
+	defaultLogger.CompareAndSwap(nil, NewConsoleLogger())
+	return defaultLogger.Load()
+}
+
+// SetDefault replaces the fallback logger used by FromContext
+func SetDefault(l *Logger) {
+	defaultLogger.Store(l)
+}
+
+// ContextValue returns an extractor that adds the value stored in a context
+// under ctxKey as a field named key. Contexts without the value are skipped.
+func ContextValue(key string, ctxKey interface{}) ContextExtractor {
+	return func(ctx context.Context, e *Event) {
+		if val := ctx.Value(ctxKey); val != nil {
+			e.Any(key, val)
+		}
+	}
+}
+
+// Ctx attaches the context to the event and adds the fields returned by the
+// logger's context extractors
+func (e *Event) Ctx(ctx context.Context) *Event {
+	if e == nil || ctx == nil {
+		return e
+	}
+	e.ctx = ctx
+	for _, extract := range e.logger.core.contextExtractors {
+		extract(ctx, e)
+	}
+	return e
+}
+
+// GetCtx returns the context attached with Ctx, or context.Background
+func (e *Event) GetCtx() context.Context {
+	if e == nil || e.ctx == nil {
+		return context.Background()
+	}
+	return e.ctx
+}
@@ -0,0 +1,152 @@
+package pdalog
+
+import (
+	"fmt"
+	"time"
+)
+
+// ContextBuilder collects typed context fields for a child logger. The fields
+// are encoded once when added, so events of the resulting logger only copy
+// the pre-encoded bytes.
+type ContextBuilder struct {
+	logger  *Logger
+	fields  map[string]interface{}
+	context [][]byte
+	// shared is set once the fields were handed to a child logger
+	shared bool
+}
+
+// WithFields starts building a child logger with several context fields:
+//
+//	reqLog := log.WithFields().Str("request_id", id).Int("attempt", 2).Logger()
+func (l *Logger) WithFields() *ContextBuilder {
+	c := &ContextBuilder{
+		logger: l,
+		fields: make(map[string]interface{}, len(l.contextFields)+4),
+	}
+	for k, v := range l.contextFields {
+		c.fields[k] = v
+	}
+	c.context = make([][]byte, len(l.context))
+	for i, context := range l.context {
+		c.context[i] = make([]byte, 0, len(context)+64)
+		c.context[i] = append(c.context[i], context...)
+	}
+	return c
+}
+
+// Logger returns a child logger carrying the collected fields. Fields added
+// to the builder afterwards do not affect the child.
+func (c *ContextBuilder) Logger() *Logger {
+	c.shared = true
+	return &Logger{
+		core:          c.logger.core,
+		parent:        c.logger,
+		contextFields: c.fields,
+		context:       c.context,
+		name:          c.logger.name,
+		nameField:     c.logger.nameField,
+	}
+}
+
+// own copies the fields once they were handed to a child logger, so adding
+// more does not change the child
+func (c *ContextBuilder) own() {
+	if !c.shared {
+		return
+	}
+	fields := make(map[string]interface{}, len(c.fields)+4)
+	for k, v := range c.fields {
+		fields[k] = v
+	}
+	context := make([][]byte, len(c.context))
+	for i := range c.context {
+		context[i] = append(make([]byte, 0, len(c.context[i])+64), c.context[i]...)
+	}
+	c.fields, c.context, c.shared = fields, context, false
+}
+
+// Str adds a string field to the context
+func (c *ContextBuilder) Str(key, val string) *ContextBuilder {
+	c.own()
+	for i, enc := range c.logger.core.encoders {
+		c.context[i] = enc.AppendString(enc.AppendKey(c.context[i], key), val)
+	}
+	c.fields[key] = val
+	return c
+}
+
+// Int adds an integer field to the context
+func (c *ContextBuilder) Int(key string, val int) *ContextBuilder {
+	c.own()
+	for i, enc := range c.logger.core.encoders {
+		c.context[i] = enc.AppendInt(enc.AppendKey(c.context[i], key), int64(val))
+	}
+	c.fields[key] = val
+	return c
+}
+
+// Bool adds a boolean field to the context
+func (c *ContextBuilder) Bool(key string, val bool) *ContextBuilder {
+	c.own()
+	for i, enc := range c.logger.core.encoders {
+		c.context[i] = enc.AppendBool(enc.AppendKey(c.context[i], key), val)
+	}
+	c.fields[key] = val
+	return c
+}
+
+// Err adds an error field to the context
+func (c *ContextBuilder) Err(err error) *ContextBuilder {
+	if err == nil {
+		return c
+	}
+	return c.Str("error", err.Error())
+}
+
+// Any adds a field with any value to the context
+func (c *ContextBuilder) Any(key string, val interface{}) *ContextBuilder {
+	c.own()
+	var marshalErr error
+	for i, enc := range c.logger.core.encoders {
+		var err error
+		if c.context[i], err = appendInterface(enc, enc.AppendKey(c.context[i], key), val); err != nil {
+			marshalErr = err
+		}
+	}
+	if marshalErr != nil {
+		c.logger.core.errorHandler(&MarshalError{Key: key, Value: val, Err: marshalErr})
+	}
+	c.fields[key] = val
+	return c
+}
+
+// Duration adds a duration field to the context
+func (c *ContextBuilder) Duration(key string, val time.Duration) *ContextBuilder {
+	c.own()
+	for i, enc := range c.logger.core.encoders {
+		c.context[i] = enc.AppendDuration(enc.AppendKey(c.context[i], key), val)
+	}
+	c.fields[key] = val
+	return c
+}
+
+// Time adds a time.Time field to the context
+func (c *ContextBuilder) Time(key string, val time.Time) *ContextBuilder {
+	c.own()
+	for i, enc := range c.logger.core.encoders {
+		c.context[i] = enc.AppendTime(enc.AppendKey(c.context[i], key), val, time.RFC3339Nano)
+	}
+	c.fields[key] = val
+	return c
+}
+
+// Hex adds a hex-encoded byte slice field to the context
+func (c *ContextBuilder) Hex(key string, val []byte) *ContextBuilder {
+	c.own()
+	for i, enc := range c.logger.core.encoders {
+		c.context[i] = enc.AppendHex(enc.AppendKey(c.context[i], key), val)
+	}
+	c.fields[key] = fmt.Sprintf("%x", val)
+	return c
+}
@@ -0,0 +1,121 @@
+package pdalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestContextBuilder(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := New(Options{
+		Writer: buf,
+		Level:  DebugLevel,
+	}).With("service", "api")
+
+	started := time.Date(2025, 8, 4, 21, 2, 0, 0, time.UTC)
+	reqLog := log.WithFields().
+		Str("request_id", "req-1").
+		Int("attempt", 2).
+		Bool("retry", true).
+		Err(errors.New("timeout")).
+		Duration("budget", time.Second).
+		Time("started", started).
+		Hex("trace", []byte{0xAB}).
+		Any("tags", []string{"a", "b"}).
+		Logger()
+
+	reqLog.Info().Str("path", "/users").Msg("handled")
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Failed to parse JSON: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"service":    "api",
+		"request_id": "req-1",
+		"attempt":    float64(2),
+		"retry":      true,
+		"error":      "timeout",
+		"budget":     float64(time.Second),
+		"started":    "2025-08-04T21:02:00Z",
+		"trace":      "ab",
+		"path":       "/users",
+	}
+	for key, value := range expected {
+		if entry[key] != value {
+			t.Errorf("Expected %s to be %v, got %v", key, value, entry[key])
+		}
+	}
+
+	// Fields keep the order they were added in, after the parent's context
+	line := buf.String()
+	if i, j := bytes.Index([]byte(line), []byte(`"service"`)), bytes.Index([]byte(line), []byte(`"request_id"`)); i > j {
+		t.Errorf("Expected parent context before builder fields, got %s", line)
+	}
+
+	// The parent logger is unaffected
+	buf.Reset()
+	log.Info().Msg("parent")
+	if bytes.Contains(buf.Bytes(), []byte("request_id")) {
+		t.Errorf("Expected parent logger not to carry child fields, got %s", buf.String())
+	}
+}
+
+func TestContextBuilderChildOfParent(t *testing.T) {
+	log := New(Options{
+		Writer: &bytes.Buffer{},
+		Level:  InfoLevel,
+	})
+	hook := NewMockHook()
+	log.AddHook(hook)
+
+	child := log.WithFields().Str("component", "db").Int("shard", 3).Logger()
+	log.SetLevel(ErrorLevel)
+
+	child.Warn().Msg("filtered by the parent's level")
+	child.Error().Msg("delivered")
+
+	if len(hook.FiredEntries) != 1 {
+		t.Fatalf("Expected 1 fired entry, got %d", len(hook.FiredEntries))
+	}
+	entry := hook.FiredEntries[0]
+	if entry["component"] != "db" || entry["shard"] != 3 {
+		t.Errorf("Expected hook entry to carry builder fields, got %v", entry)
+	}
+}
+
+func TestContextBuilderReuse(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := New(Options{Writer: buf, Level: InfoLevel})
+	hook := NewMockHook()
+	log.AddHook(hook)
+
+	b := log.WithFields().Str("a", "1")
+	first := b.Logger()
+	second := b.Str("b", "2").Logger()
+
+	first.Info().Msg("first")
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Failed to parse JSON: %v", err)
+	}
+	if _, ok := entry["b"]; ok || entry["a"] != "1" {
+		t.Errorf("Expected the first logger to keep its fields, got %v", entry)
+	}
+	if _, ok := hook.FiredEntries[0]["b"]; ok {
+		t.Errorf("Expected the hook entry to keep the first logger's fields, got %v", hook.FiredEntries[0])
+	}
+
+	buf.Reset()
+	second.Info().Msg("second")
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Failed to parse JSON: %v", err)
+	}
+	if entry["a"] != "1" || entry["b"] != "2" {
+		t.Errorf("Expected both fields on the second logger, got %v", entry)
+	}
+}
@@ -0,0 +1,124 @@
+package pdalog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+type testContextKey string
+
+func TestWithContextFromContext(t *testing.T) {
+	log := New(Options{Writer: &bytes.Buffer{}})
+	ctx := WithContext(context.Background(), log)
+
+	if FromContext(ctx) != log {
+		t.Error("Expected FromContext to return the stored logger")
+	}
+
+	// Without a logger, FromContext falls back to the default logger
+	if FromContext(context.Background()) != Default() {
+		t.Error("Expected FromContext to fall back to the default logger")
+	}
+
+	fallback := New(Options{Writer: &bytes.Buffer{}})
+	previous := Default()
+	SetDefault(fallback)
+	defer SetDefault(previous)
+
+	if FromContext(context.Background()) != fallback {
+		t.Error("Expected FromContext to return the logger set with SetDefault")
+	}
+}
+
+func TestEventCtx(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := New(Options{
+		Writer: buf,
+		Level:  DebugLevel,
+		ContextExtractors: []ContextExtractor{
+			ContextValue("request_id", testContextKey("request_id")),
+			ContextValue("tenant", testContextKey("tenant")),
+			func(ctx context.Context, e *Event) {
+				if user, ok := ctx.Value(testContextKey("user")).(string); ok {
+					e.Str("user", user)
+				}
+			},
+		},
+	})
+
+	ctx := context.WithValue(context.Background(), testContextKey("request_id"), "req-1")
+	ctx = context.WithValue(ctx, testContextKey("user"), "alice")
+
+	// Children share the extractors configured on the root
+	log.With("component", "api").Info().Ctx(ctx).Msg("handled")
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Failed to parse JSON: %v", err)
+	}
+	if entry["request_id"] != "req-1" {
+		t.Errorf("Expected request_id to be 'req-1', got %v", entry["request_id"])
+	}
+	if entry["user"] != "alice" {
+		t.Errorf("Expected user to be 'alice', got %v", entry["user"])
+	}
+	if _, ok := entry["tenant"]; ok {
+		t.Errorf("Expected missing context value to be skipped, got %v", entry["tenant"])
+	}
+}
+
+func TestEventGetCtx(t *testing.T) {
+	log := New(Options{Writer: &bytes.Buffer{}})
+	ctx := context.WithValue(context.Background(), testContextKey("k"), "v")
+
+	e := log.Info().Ctx(ctx)
+	if e.GetCtx() != ctx {
+		t.Error("Expected GetCtx to return the attached context")
+	}
+	e.Msg("done")
+
+	if log.Info().GetCtx() != context.Background() {
+		t.Error("Expected GetCtx to default to context.Background")
+	}
+
+	var nilEvent *Event
+	if nilEvent.Ctx(ctx) != nil {
+		t.Error("Expected nil.Ctx to return nil")
+	}
+}
+
+// mockContextHook records the contexts it was fired with
+type mockContextHook struct {
+	*MockHook
+	contexts []context.Context
+}
+
+func (h *mockContextHook) FireContext(ctx context.Context, entry map[string]interface{}) error {
+	h.contexts = append(h.contexts, ctx)
+	return h.Fire(entry)
+}
+
+func TestContextHookReceivesEventContext(t *testing.T) {
+	log := New(Options{Writer: &bytes.Buffer{}})
+	hook := &mockContextHook{MockHook: NewMockHook()}
+	log.AddHook(hook)
+
+	ctx := context.WithValue(context.Background(), testContextKey("k"), "v")
+	log.Info().Ctx(ctx).Msg("with context")
+	log.Info().Msg("without context")
+
+	if len(hook.contexts) != 2 {
+		t.Fatalf("Expected FireContext to be called twice, got %d", len(hook.contexts))
+	}
+	if hook.contexts[0] != ctx {
+		t.Error("Expected the hook to receive the event's context")
+	}
+	if hook.contexts[1] != context.Background() {
+		t.Error("Expected context.Background for events without a context")
+	}
+	if len(hook.FiredEntries) != 2 {
+		t.Errorf("Expected 2 fired entries, got %d", len(hook.FiredEntries))
+	}
+}
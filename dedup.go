@@ -0,0 +1,340 @@
+package pdalog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DedupOptions configures a DedupHook or DedupWriter
+type DedupOptions struct {
+	// Window is how long repeats of an event are suppressed after it was
+	// logged, 10s when zero
+	Window time.Duration
+	// Fields are the fields, besides level and message, that must be equal
+	// for events to count as repeats
+	Fields []string
+	// MaxKeys caps the number of distinct events tracked at once, 1000 when
+	// zero; further events are passed through without deduplication
+	MaxKeys int
+	// ErrorHandler receives errors writing the summaries, which are written
+	// after the logger has returned; errors are printed to os.Stderr when nil
+	ErrorHandler ErrorHandler
+}
+
+// withDefaults returns the options with zero values replaced by defaults
+func (o DedupOptions) withDefaults() DedupOptions {
+	if o.Window <= 0 {
+		o.Window = 10 * time.Second
+	}
+	if o.MaxKeys <= 0 {
+		o.MaxKeys = 1000
+	}
+	if o.ErrorHandler == nil {
+		o.ErrorHandler = defaultErrorHandler
+	}
+	return o
+}
+
+// dedupWindow tracks the repeats of an event within its window
+type dedupWindow struct {
+	repeats int
+	timer   *time.Timer
+	// summarize writes the summary when the window closes with repeats
+	summarize func(repeats int)
+}
+
+// deduper suppresses repeated events and summarizes them when their window closes
+type deduper struct {
+	opts DedupOptions
+
+	mu      sync.Mutex
+	windows map[string]*dedupWindow
+	closed  bool
+}
+
+// newDeduper creates a deduper with defaults applied to opts
+func newDeduper(opts DedupOptions) *deduper {
+	return &deduper{opts: opts.withDefaults(), windows: make(map[string]*dedupWindow)}
+}
+
+// repeated reports whether the event with the key repeats one logged within
+// the window, counting it if so. Otherwise it opens a window for the key,
+// calling summarize with the number of repeats when the window closes.
+func (d *deduper) repeated(key string, summarize func(repeats int)) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return false
+	}
+	if w, ok := d.windows[key]; ok {
+		w.repeats++
+		return true
+	}
+	if len(d.windows) >= d.opts.MaxKeys {
+		return false
+	}
+
+	w := &dedupWindow{summarize: summarize}
+	w.timer = time.AfterFunc(d.opts.Window, func() { d.closeWindow(key, w) })
+	d.windows[key] = w
+	return false
+}
+
+// closeWindow ends the window of the key and writes its summary
+func (d *deduper) closeWindow(key string, w *dedupWindow) {
+	d.mu.Lock()
+	if d.windows[key] != w {
+		d.mu.Unlock()
+		return
+	}
+	delete(d.windows, key)
+	repeats := w.repeats
+	d.mu.Unlock()
+
+	if repeats > 0 {
+		w.summarize(repeats)
+	}
+}
+
+// close ends all windows, writing their summaries
+func (d *deduper) close() {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return
+	}
+	d.closed = true
+	windows := d.windows
+	d.windows = nil
+	d.mu.Unlock()
+
+	// Windows whose timer already fired are no longer in the map once
+	// closed is set, so every summary is written exactly once
+	for _, w := range windows {
+		w.timer.Stop()
+		if w.repeats > 0 {
+			w.summarize(w.repeats)
+		}
+	}
+}
+
+// dedupMessage is the message of summary entries
+func dedupMessage(repeats int) string {
+	return fmt.Sprintf("message repeated %d times", repeats)
+}
+
+// DedupHook wraps a Hook and suppresses entries with the same level, message
+// and selected fields within a window. The first entry is fired right away;
+// when the window closes, an entry such as "message repeated 532 times" with
+// the original message in repeated_message is fired for the suppressed ones.
+type DedupHook struct {
+	hook  Hook
+	dedup *deduper
+}
+
+// NewDedupHook wraps the hook with deduplication
+func NewDedupHook(hook Hook, opts DedupOptions) *DedupHook {
+	return &DedupHook{hook: hook, dedup: newDeduper(opts)}
+}
+
+// Fire fires the entry unless it repeats one fired within the window
+func (h *DedupHook) Fire(entry map[string]interface{}) error {
+	return h.FireContext(context.Background(), entry)
+}
+
+// FireContext fires the entry unless it repeats one fired within the window,
+// passing the event's context on if the wrapped hook is a ContextHook. The
+// summary is fired with the context of the first entry of the window.
+func (h *DedupHook) FireContext(ctx context.Context, entry map[string]interface{}) error {
+	key := h.key(entry)
+	if h.dedup.repeated(key, func(repeats int) { h.summarize(ctx, entry, repeats) }) {
+		return nil
+	}
+	return h.fire(ctx, entry)
+}
+
+// fire fires the wrapped hook with the context if it is a ContextHook
+func (h *DedupHook) fire(ctx context.Context, entry map[string]interface{}) error {
+	if ch, ok := h.hook.(ContextHook); ok {
+		return ch.FireContext(ctx, entry)
+	}
+	return h.hook.Fire(entry)
+}
+
+// Levels returns the levels of the wrapped hook
+func (h *DedupHook) Levels() []Level {
+	return h.hook.Levels()
+}
+
+// Flush flushes the wrapped hook if it buffers entries
+func (h *DedupHook) Flush(ctx context.Context) error {
+	return flush(ctx, h.hook)
+}
+
+// Close fires the summaries of the open windows and closes the wrapped hook
+// if it implements io.Closer
+func (h *DedupHook) Close() error {
+	h.dedup.close()
+	if c, ok := h.hook.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// key identifies repeats of the entry
+func (h *DedupHook) key(entry map[string]interface{}) string {
+	var b strings.Builder
+	b.WriteString(formatFieldValue(entry["level"]))
+	b.WriteByte(0)
+	b.WriteString(formatFieldValue(entry["message"]))
+	for _, field := range h.dedup.opts.Fields {
+		b.WriteByte(0)
+		if val, ok := entry[field]; ok {
+			b.WriteString(formatFieldValue(val))
+		}
+	}
+	return b.String()
+}
+
+// summarize fires the summary of the suppressed repeats of entry
+func (h *DedupHook) summarize(ctx context.Context, entry map[string]interface{}, repeats int) {
+	summary := map[string]interface{}{
+		"time":             timeNow().Format(time.RFC3339),
+		"level":            entry["level"],
+		"message":          dedupMessage(repeats),
+		"repeated_message": entry["message"],
+		"repeated":         repeats,
+	}
+	for _, field := range h.dedup.opts.Fields {
+		if val, ok := entry[field]; ok {
+			summary[field] = val
+		}
+	}
+	if err := h.fire(ctx, summary); err != nil {
+		h.dedup.opts.ErrorHandler(&HookError{Hook: h.hook, Entry: summary, Err: err})
+	}
+}
+
+// DedupWriter wraps an io.Writer receiving JSON log lines, such as
+// Options.Writer or an Output's writer, and suppresses lines with the same
+// level, message and selected fields within a window like DedupHook. Lines
+// that are not JSON objects are written unchanged.
+type DedupWriter struct {
+	// mu serializes writes to w, summaries are written from timer goroutines
+	mu    sync.Mutex
+	w     io.Writer
+	dedup *deduper
+}
+
+// NewDedupWriter wraps w with deduplication
+func NewDedupWriter(w io.Writer, opts DedupOptions) *DedupWriter {
+	return &DedupWriter{w: w, dedup: newDeduper(opts)}
+}
+
+// Write writes the lines in p that do not repeat one written within the window
+func (w *DedupWriter) Write(p []byte) (int, error) {
+	var out []byte
+	for _, line := range bytes.SplitAfter(p, []byte{'\n'}) {
+		if len(line) == 0 {
+			continue
+		}
+		fields, err := parseConsoleFields(line)
+		if err == nil {
+			key := w.key(fields)
+			if w.dedup.repeated(key, func(repeats int) { w.summarize(fields, repeats) }) {
+				continue
+			}
+		}
+		out = append(out, line...)
+	}
+
+	if len(out) > 0 {
+		w.mu.Lock()
+		_, err := w.w.Write(out)
+		w.mu.Unlock()
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush flushes the wrapped writer if it buffers lines
+func (w *DedupWriter) Flush(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return flush(ctx, w.w)
+}
+
+// Close writes the summaries of the open windows and closes the wrapped
+// writer if it implements io.Closer
+func (w *DedupWriter) Close() error {
+	w.dedup.close()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if c, ok := w.w.(io.Closer); ok && !isStdStream(w.w) {
+		return c.Close()
+	}
+	return nil
+}
+
+// key identifies repeats of a parsed line
+func (w *DedupWriter) key(fields []consoleField) string {
+	var b strings.Builder
+	b.Write(consoleFieldValue(fields, "level"))
+	b.WriteByte(0)
+	b.Write(consoleFieldValue(fields, "message"))
+	for _, field := range w.dedup.opts.Fields {
+		b.WriteByte(0)
+		b.Write(consoleFieldValue(fields, field))
+	}
+	return b.String()
+}
+
+// summarize writes the summary of the suppressed repeats of a parsed line
+func (w *DedupWriter) summarize(fields []consoleField, repeats int) {
+	enc := JSONEncoder{}
+	line := enc.AppendBeginMarker(nil)
+	line = enc.AppendString(enc.AppendKey(line, "time"), timeNow().Format(time.RFC3339))
+	line = appendRawField(enc.AppendKey(line, "level"), consoleFieldValue(fields, "level"))
+	line = enc.AppendString(enc.AppendKey(line, "message"), dedupMessage(repeats))
+	line = appendRawField(enc.AppendKey(line, "repeated_message"), consoleFieldValue(fields, "message"))
+	line = enc.AppendInt(enc.AppendKey(line, "repeated"), int64(repeats))
+	for _, field := range w.dedup.opts.Fields {
+		if val := consoleFieldValue(fields, field); val != nil {
+			line = appendRawField(enc.AppendKey(line, field), val)
+		}
+	}
+	line = enc.AppendLineBreak(enc.AppendEndMarker(line))
+
+	w.mu.Lock()
+	_, err := w.w.Write(line)
+	w.mu.Unlock()
+	if err != nil {
+		w.dedup.opts.ErrorHandler(&WriteError{Writer: w.w, Entry: line, Err: err})
+	}
+}
+
+// consoleFieldValue returns the raw JSON value of the first field with the key, nil if missing
+func consoleFieldValue(fields []consoleField, key string) json.RawMessage {
+	for _, f := range fields {
+		if f.key == key {
+			return f.value
+		}
+	}
+	return nil
+}
+
+// appendRawField appends a raw JSON value, null when missing
+func appendRawField(dst []byte, val json.RawMessage) []byte {
+	if val == nil {
+		return append(dst, "null"...)
+	}
+	return append(dst, val...)
+}
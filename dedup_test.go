@@ -0,0 +1,151 @@
+package pdalog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDedupHook(t *testing.T) {
+	setClock(t, time.Date(2025, 8, 4, 21, 2, 0, 0, time.UTC))
+	mock := NewMockHook()
+	hook := NewDedupHook(mock, DedupOptions{Window: 20 * time.Millisecond, Fields: []string{"host"}})
+
+	log := New(Options{Writer: &bytes.Buffer{}})
+	log.AddHook(hook)
+	for i := 0; i < 5; i++ {
+		log.Warn().Str("host", "db1").Int("attempt", i).Msg("connection refused")
+	}
+	log.Warn().Str("host", "db2").Msg("connection refused")
+	log.Error().Str("host", "db1").Msg("connection refused")
+
+	if got := len(firedEntries(mock)); got != 3 {
+		t.Fatalf("Expected 3 entries before the window closes, got %d", got)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(firedEntries(mock)) < 4 {
+		if time.Now().After(deadline) {
+			t.Fatal("Summary was not fired")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	summary := firedEntries(mock)[3]
+	if summary["message"] != "message repeated 4 times" || summary["repeated_message"] != "connection refused" ||
+		summary["repeated"] != 4 || summary["host"] != "db1" || summary["level"] != "warn" {
+		t.Errorf("Unexpected summary %v", summary)
+	}
+
+	// After the window the event is fired again
+	log.Warn().Str("host", "db1").Msg("connection refused")
+	if got := len(firedEntries(mock)); got != 5 {
+		t.Errorf("Expected the event to be fired after the window, got %d entries", got)
+	}
+}
+
+func TestDedupHookClose(t *testing.T) {
+	mock := NewMockHook()
+	hook := NewDedupHook(mock, DedupOptions{Window: time.Hour})
+
+	entry := map[string]interface{}{"level": "info", "message": "tick"}
+	for i := 0; i < 3; i++ {
+		_ = hook.Fire(entry)
+	}
+	_ = hook.Fire(map[string]interface{}{"level": "info", "message": "once"})
+
+	if err := hook.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	entries := firedEntries(mock)
+	if len(entries) != 3 || entries[2]["message"] != "message repeated 2 times" {
+		t.Errorf("Expected the open window to be summarized on Close, got %v", entries)
+	}
+
+	// Once closed, entries pass through
+	_ = hook.Fire(entry)
+	if len(firedEntries(mock)) != 4 {
+		t.Error("Expected entries to pass through after Close")
+	}
+}
+
+func TestDedupHookContext(t *testing.T) {
+	inner := &mockContextHook{MockHook: NewMockHook()}
+	hook := NewDedupHook(inner, DedupOptions{Window: time.Hour})
+	log := New(Options{Writer: &bytes.Buffer{}})
+	log.AddHook(hook)
+
+	ctx := context.WithValue(context.Background(), testContextKey("request_id"), "req-1")
+	log.Warn().Ctx(ctx).Msg("slow")
+	log.Warn().Ctx(ctx).Msg("slow")
+	if err := hook.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	if len(inner.contexts) != 2 {
+		t.Fatalf("Expected the entry and the summary to be fired with FireContext, got %d", len(inner.contexts))
+	}
+	for _, got := range inner.contexts {
+		if got != ctx {
+			t.Errorf("Expected the event's context, got %v", got)
+		}
+	}
+}
+
+func TestDedupHookMaxKeys(t *testing.T) {
+	mock := NewMockHook()
+	hook := NewDedupHook(mock, DedupOptions{Window: time.Hour, MaxKeys: 1})
+	defer hook.Close()
+
+	for i := 0; i < 2; i++ {
+		_ = hook.Fire(map[string]interface{}{"message": "tracked"})
+		_ = hook.Fire(map[string]interface{}{"message": "untracked"})
+	}
+	if got := len(firedEntries(mock)); got != 3 {
+		t.Errorf("Expected untracked events to pass through, got %d entries", got)
+	}
+}
+
+func TestDedupWriter(t *testing.T) {
+	setClock(t, time.Date(2025, 8, 4, 21, 2, 0, 0, time.UTC))
+	buf := &bytes.Buffer{}
+	w := NewDedupWriter(buf, DedupOptions{Window: time.Hour, Fields: []string{"host"}})
+	log := New(Options{Writer: w})
+
+	for i := 0; i < 3; i++ {
+		log.Warn().Str("host", "db1").Msg("disk slow")
+	}
+	log.Warn().Str("host", "db2").Msg("disk slow")
+	_, _ = w.Write([]byte("not json\nnot json\n"))
+
+	if got := strings.Count(buf.String(), `"message":"disk slow"`); got != 2 {
+		t.Fatalf("Expected 2 lines before the window closes, got %d", got)
+	}
+	if strings.Count(buf.String(), "not json\n") != 2 {
+		t.Errorf("Expected non-JSON lines to pass through, got %q", buf.String())
+	}
+
+	if err := log.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	last := lines[len(lines)-1]
+	expected := `{"time":"2025-08-04T21:02:00Z","level":"warn","message":"message repeated 2 times","repeated_message":"disk slow","repeated":2,"host":"db1"}`
+	if last != expected {
+		t.Errorf("Expected summary %s, got %s", expected, last)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal([]byte(last), &decoded); err != nil {
+		t.Errorf("Summary is not valid JSON: %v", err)
+	}
+}
+
+// firedEntries returns a snapshot of the entries fired to the hook
+func firedEntries(h *MockHook) []map[string]interface{} {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]map[string]interface{}(nil), h.FiredEntries...)
+}
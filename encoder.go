@@ -0,0 +1,49 @@
+package pdalog
+
+import "time"
+
+// Encoder renders log lines by appending encoded fragments to a byte slice.
+// Implementations must not retain dst and should avoid allocating for the
+// typed Append methods.
+type Encoder interface {
+	// AppendBeginMarker starts a new log line
+	AppendBeginMarker(dst []byte) []byte
+	// AppendEndMarker finishes a log line before the line break
+	AppendEndMarker(dst []byte) []byte
+	// AppendLineBreak terminates a log line
+	AppendLineBreak(dst []byte) []byte
+	// AppendKey appends a field separator if needed, followed by the key
+	AppendKey(dst []byte, key string) []byte
+	// AppendFields appends fields encoded by this encoder into a separate
+	// buffer, preceded by a field separator if needed
+	AppendFields(dst []byte, fields []byte) []byte
+	// AppendString appends a string value
+	AppendString(dst []byte, val string) []byte
+	// AppendInt appends an integer value
+	AppendInt(dst []byte, val int64) []byte
+	// AppendBool appends a boolean value
+	AppendBool(dst []byte, val bool) []byte
+	// AppendDuration appends a duration value as integer nanoseconds
+	AppendDuration(dst []byte, val time.Duration) []byte
+	// AppendTime appends a time value formatted with the given layout
+	AppendTime(dst []byte, val time.Time, format string) []byte
+	// AppendHex appends a byte slice as a lowercase hex string
+	AppendHex(dst []byte, val []byte) []byte
+	// AppendInterface appends an arbitrary value
+	AppendInterface(dst []byte, val interface{}) []byte
+}
+
+// interfaceAppender is implemented by the built-in encoders to report values
+// that cannot be marshaled, which AppendInterface replaces with a placeholder
+type interfaceAppender interface {
+	appendInterface(dst []byte, val interface{}) ([]byte, error)
+}
+
+// appendInterface appends an arbitrary value with enc, returning the marshal
+// error of encoders implementing interfaceAppender
+func appendInterface(enc Encoder, dst []byte, val interface{}) ([]byte, error) {
+	if a, ok := enc.(interfaceAppender); ok {
+		return a.appendInterface(dst, val)
+	}
+	return enc.AppendInterface(dst, val), nil
+}
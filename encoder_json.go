@@ -0,0 +1,173 @@
+package pdalog
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+const hexDigits = "0123456789abcdef"
+
+// noEscape marks the ASCII characters that can be copied verbatim into a JSON string
+var noEscape = func() [utf8.RuneSelf]bool {
+	var t [utf8.RuneSelf]bool
+	for c := 0x20; c < utf8.RuneSelf; c++ {
+		t[c] = c != '"' && c != '\\'
+	}
+	return t
+}()
+
+// JSONEncoder renders log lines as JSON objects, one per line.
+// It is the default encoder.
+type JSONEncoder struct{}
+
+// AppendBeginMarker opens a JSON object
+func (JSONEncoder) AppendBeginMarker(dst []byte) []byte {
+	return append(dst, '{')
+}
+
+// AppendEndMarker closes a JSON object
+func (JSONEncoder) AppendEndMarker(dst []byte) []byte {
+	return append(dst, '}')
+}
+
+// AppendLineBreak terminates a log line
+func (JSONEncoder) AppendLineBreak(dst []byte) []byte {
+	return append(dst, '\n')
+}
+
+// AppendKey appends a field separator if needed, followed by the quoted key
+func (enc JSONEncoder) AppendKey(dst []byte, key string) []byte {
+	if len(dst) > 0 && dst[len(dst)-1] != '{' {
+		dst = append(dst, ',')
+	}
+	dst = enc.AppendString(dst, key)
+	return append(dst, ':')
+}
+
+// AppendFields appends pre-encoded fields, separated by a comma
+func (JSONEncoder) AppendFields(dst []byte, fields []byte) []byte {
+	if len(fields) == 0 {
+		return dst
+	}
+	if len(dst) > 0 && dst[len(dst)-1] != '{' {
+		dst = append(dst, ',')
+	}
+	return append(dst, fields...)
+}
+
+// AppendString appends a quoted and escaped JSON string
+func (JSONEncoder) AppendString(dst []byte, s string) []byte {
+	dst = append(dst, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			if noEscape[c] {
+				i++
+				continue
+			}
+			dst = append(dst, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				dst = append(dst, '\\', c)
+			case '\n':
+				dst = append(dst, '\\', 'n')
+			case '\r':
+				dst = append(dst, '\\', 'r')
+			case '\t':
+				dst = append(dst, '\\', 't')
+			default:
+				dst = append(dst, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, `\ufffd`...)
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	dst = append(dst, s[start:]...)
+	return append(dst, '"')
+}
+
+// AppendInt appends an integer
+func (JSONEncoder) AppendInt(dst []byte, val int64) []byte {
+	return strconv.AppendInt(dst, val, 10)
+}
+
+// AppendBool appends a boolean
+func (JSONEncoder) AppendBool(dst []byte, val bool) []byte {
+	return strconv.AppendBool(dst, val)
+}
+
+// AppendDuration appends a duration as integer nanoseconds, matching encoding/json
+func (enc JSONEncoder) AppendDuration(dst []byte, val time.Duration) []byte {
+	return enc.AppendInt(dst, int64(val))
+}
+
+// AppendTime appends a time quoted in RFC3339Nano, matching encoding/json
+func (JSONEncoder) AppendTime(dst []byte, val time.Time, format string) []byte {
+	dst = append(dst, '"')
+	dst = val.AppendFormat(dst, format)
+	return append(dst, '"')
+}
+
+// AppendHex appends a byte slice as a quoted hex string
+func (JSONEncoder) AppendHex(dst []byte, val []byte) []byte {
+	dst = append(dst, '"')
+	dst = hex.AppendEncode(dst, val)
+	return append(dst, '"')
+}
+
+// AppendInterface appends an arbitrary value, avoiding reflection for common types
+func (enc JSONEncoder) AppendInterface(dst []byte, val interface{}) []byte {
+	dst, _ = enc.appendInterface(dst, val)
+	return dst
+}
+
+// appendInterface implements interfaceAppender
+func (enc JSONEncoder) appendInterface(dst []byte, val interface{}) ([]byte, error) {
+	if dst, ok := appendCommonValue(enc, dst, val); ok {
+		return dst, nil
+	}
+	if val == nil {
+		return append(dst, "null"...), nil
+	}
+	data, err := json.Marshal(val)
+	if err != nil {
+		return enc.AppendString(dst, "marshaling error: "+err.Error()), err
+	}
+	return append(dst, data...), nil
+}
+
+// appendCommonValue encodes the value types every encoder renders the same
+// way, reporting false for types it does not handle
+func appendCommonValue(enc Encoder, dst []byte, val interface{}) ([]byte, bool) {
+	switch v := val.(type) {
+	case string:
+		return enc.AppendString(dst, v), true
+	case int:
+		return enc.AppendInt(dst, int64(v)), true
+	case int64:
+		return enc.AppendInt(dst, v), true
+	case bool:
+		return enc.AppendBool(dst, v), true
+	case time.Duration:
+		return enc.AppendDuration(dst, v), true
+	case time.Time:
+		return enc.AppendTime(dst, v, time.RFC3339Nano), true
+	case error:
+		return enc.AppendString(dst, v.Error()), true
+	}
+	return dst, false
+}
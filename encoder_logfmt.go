@@ -0,0 +1,143 @@
+package pdalog
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// LogfmtEncoder renders log lines in logfmt, e.g.
+// time=2025-08-04T21:02:00Z level=info message="request done" status=200.
+// Values containing spaces, '=', quotes or control characters are quoted
+// using Go string escaping.
+type LogfmtEncoder struct{}
+
+// AppendBeginMarker is a no-op, logfmt lines have no opening marker
+func (LogfmtEncoder) AppendBeginMarker(dst []byte) []byte {
+	return dst
+}
+
+// AppendEndMarker is a no-op, logfmt lines have no closing marker
+func (LogfmtEncoder) AppendEndMarker(dst []byte) []byte {
+	return dst
+}
+
+// AppendLineBreak terminates a log line
+func (LogfmtEncoder) AppendLineBreak(dst []byte) []byte {
+	return append(dst, '\n')
+}
+
+// AppendKey appends a space separator if needed, followed by the key and '='.
+// Characters not allowed in logfmt keys are replaced with '_'.
+func (LogfmtEncoder) AppendKey(dst []byte, key string) []byte {
+	if len(dst) > 0 {
+		dst = append(dst, ' ')
+	}
+	if key == "" {
+		dst = append(dst, '_')
+	}
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if c <= ' ' || c == '=' || c == '"' || c == 0x7f {
+			c = '_'
+		}
+		dst = append(dst, c)
+	}
+	return append(dst, '=')
+}
+
+// AppendFields appends pre-encoded fields, separated by a space
+func (LogfmtEncoder) AppendFields(dst []byte, fields []byte) []byte {
+	if len(fields) == 0 {
+		return dst
+	}
+	if len(dst) > 0 {
+		dst = append(dst, ' ')
+	}
+	return append(dst, fields...)
+}
+
+// AppendString appends a string, quoting it only when necessary
+func (LogfmtEncoder) AppendString(dst []byte, val string) []byte {
+	if logfmtNeedsQuoting(val) {
+		return strconv.AppendQuote(dst, val)
+	}
+	return append(dst, val...)
+}
+
+// AppendInt appends an integer
+func (LogfmtEncoder) AppendInt(dst []byte, val int64) []byte {
+	return strconv.AppendInt(dst, val, 10)
+}
+
+// AppendBool appends a boolean
+func (LogfmtEncoder) AppendBool(dst []byte, val bool) []byte {
+	return strconv.AppendBool(dst, val)
+}
+
+// AppendDuration appends a duration as integer nanoseconds, matching JSONEncoder
+func (enc LogfmtEncoder) AppendDuration(dst []byte, val time.Duration) []byte {
+	return enc.AppendInt(dst, int64(val))
+}
+
+// AppendTime appends a formatted time, quoting it when the layout produces spaces
+func (LogfmtEncoder) AppendTime(dst []byte, val time.Time, format string) []byte {
+	start := len(dst)
+	dst = val.AppendFormat(dst, format)
+	if formatted := dst[start:]; logfmtNeedsQuoting(string(formatted)) {
+		return strconv.AppendQuote(dst[:start], string(formatted))
+	}
+	return dst
+}
+
+// AppendHex appends a byte slice as a hex string
+func (LogfmtEncoder) AppendHex(dst []byte, val []byte) []byte {
+	return hex.AppendEncode(dst, val)
+}
+
+// AppendInterface appends an arbitrary value; types without a native
+// rendering are marshaled to JSON and appended as a string
+func (enc LogfmtEncoder) AppendInterface(dst []byte, val interface{}) []byte {
+	dst, _ = enc.appendInterface(dst, val)
+	return dst
+}
+
+// appendInterface implements interfaceAppender
+func (enc LogfmtEncoder) appendInterface(dst []byte, val interface{}) ([]byte, error) {
+	if dst, ok := appendCommonValue(enc, dst, val); ok {
+		return dst, nil
+	}
+	if val == nil {
+		return append(dst, "null"...), nil
+	}
+	data, err := json.Marshal(val)
+	if err != nil {
+		return enc.AppendString(dst, "marshaling error: "+err.Error()), err
+	}
+	return enc.AppendString(dst, string(data)), nil
+}
+
+// logfmtNeedsQuoting reports whether a value must be quoted to stay parseable
+func logfmtNeedsQuoting(s string) bool {
+	if s == "" {
+		return true
+	}
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			if c <= ' ' || c == '=' || c == '"' || c == '\\' || c == 0x7f {
+				return true
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			return true
+		}
+		i += size
+	}
+	return false
+}
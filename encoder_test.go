@@ -0,0 +1,91 @@
+package pdalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestLogfmtEncoder(t *testing.T) {
+	fixed := time.Date(2025, 8, 4, 21, 2, 0, 0, time.UTC)
+	timeNow = func() time.Time { return fixed }
+	defer func() { timeNow = time.Now }()
+
+	buf := &bytes.Buffer{}
+	log := New(Options{
+		Writer:  buf,
+		Level:   DebugLevel,
+		Encoder: LogfmtEncoder{},
+	}).With("service", "user api")
+
+	log.Info().
+		Str("plain", "value").
+		Str("equals", "a=b").
+		Str("multiline", "line1\nline2").
+		Str("quoted", `say "hi"`).
+		Str("empty", "").
+		Str("bad key", "x").
+		Int("status", 200).
+		Bool("ok", true).
+		Duration("elapsed", 150*time.Millisecond).
+		Time("started", fixed).
+		Hex("sig", []byte{0xDE, 0xAD}).
+		Any("meta", map[string]int{"id": 1}).
+		Msg("request done")
+
+	expected := `time=2025-08-04T21:02:00Z level=info message="request done" service="user api" ` +
+		`plain=value equals="a=b" multiline="line1\nline2" quoted="say \"hi\"" empty="" bad_key=x ` +
+		`status=200 ok=true elapsed=150000000 started=2025-08-04T21:02:00Z sig=dead meta="{\"id\":1}"` + "\n"
+	if buf.String() != expected {
+		t.Errorf("Unexpected logfmt line\n got: %s\nwant: %s", buf.String(), expected)
+	}
+}
+
+func TestLogfmtEncoderQuotedTimeFormat(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := New(Options{
+		Writer:     buf,
+		Level:      DebugLevel,
+		TimeFormat: time.RFC1123,
+		Encoder:    LogfmtEncoder{},
+	})
+
+	log.Info().Msg("x")
+
+	line := buf.String()
+	if line[:6] != `time="` {
+		t.Errorf("Expected time with spaces to be quoted, got %s", line)
+	}
+}
+
+func TestEncodersRenderValuesConsistently(t *testing.T) {
+	started := time.Date(2025, 8, 4, 21, 2, 0, 123, time.UTC)
+	elapsed := 1500 * time.Millisecond
+	data := []byte{0x01, 0xAB}
+
+	jsonBuf := &bytes.Buffer{}
+	New(Options{Writer: jsonBuf, Level: DebugLevel}).Info().
+		Duration("elapsed", elapsed).Time("started", started).Hex("data", data).Msg("x")
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(jsonBuf.Bytes(), &entry); err != nil {
+		t.Fatalf("Failed to parse JSON: %v", err)
+	}
+
+	logfmtBuf := &bytes.Buffer{}
+	New(Options{Writer: logfmtBuf, Level: DebugLevel, Encoder: LogfmtEncoder{}}).Info().
+		Duration("elapsed", elapsed).Time("started", started).Hex("data", data).Msg("x")
+
+	line := logfmtBuf.String()
+	for key, jsonValue := range map[string]string{
+		"elapsed": strconv.FormatFloat(entry["elapsed"].(float64), 'f', -1, 64),
+		"started": entry["started"].(string),
+		"data":    entry["data"].(string),
+	} {
+		if !bytes.Contains([]byte(line), []byte(" "+key+"="+jsonValue)) {
+			t.Errorf("Expected logfmt line to contain %s=%s, got %s", key, jsonValue, line)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package pdalog
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+)
+
+// ErrorHandler is called for errors that occur while logging, such as a
+// failing writer or hook. Errors are of type *WriteError, *HookError,
+// *SyncError or *MarshalError. The handler may be called concurrently.
+type ErrorHandler func(err error)
+
+// WriteError is reported when the writer fails to write an entry
+type WriteError struct {
+	// Writer is the writer that failed
+	Writer io.Writer
+	// Entry is the encoded log line that was not written
+	Entry []byte
+	Err   error
+}
+
+// Error implements error
+func (e *WriteError) Error() string {
+	return fmt.Sprintf("writing log entry to %T: %v", e.Writer, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *WriteError) Unwrap() error {
+	return e.Err
+}
+
+// HookError is reported when a hook fails to fire
+type HookError struct {
+	// Hook is the hook that failed
+	Hook Hook
+	// Entry is the entry the hook was fired with
+	Entry map[string]interface{}
+	Err   error
+}
+
+// Error implements error
+func (e *HookError) Error() string {
+	return fmt.Sprintf("firing hook %T: %v", e.Hook, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *HookError) Unwrap() error {
+	return e.Err
+}
+
+// SyncError is reported when flushing before a Fatal exit fails
+type SyncError struct {
+	Err error
+}
+
+// Error implements error
+func (e *SyncError) Error() string {
+	return fmt.Sprintf("syncing logger: %v", e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *SyncError) Unwrap() error {
+	return e.Err
+}
+
+// MarshalError is reported when a field value cannot be encoded; the field
+// is logged with a "marshaling error" placeholder instead
+type MarshalError struct {
+	// Key is the key of the field
+	Key   string
+	Value interface{}
+	Err   error
+}
+
+// Error implements error
+func (e *MarshalError) Error() string {
+	return fmt.Sprintf("marshaling field %q of type %T: %v", e.Key, e.Value, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *MarshalError) Unwrap() error {
+	return e.Err
+}
+
+// defaultErrorHandler prints errors to os.Stderr
+func defaultErrorHandler(err error) {
+	_, _ = fmt.Fprintf(os.Stderr, "pdalog: %v\n", err)
+}
+
+// Stats holds counters of a logger, shared by a root logger and its children
+type Stats struct {
+	// Written is the number of lines written, counted once per output
+	Written uint64
+	// Dropped is the number of lines lost because an output's writer failed
+	Dropped uint64
+	// HookFailures is the number of times a hook returned an error
+	HookFailures uint64
+	// SampledOut is the number of events dropped by a Sampler
+	SampledOut uint64
+}
+
+// loggerStats are the counters behind Stats
+type loggerStats struct {
+	written      atomic.Uint64
+	dropped      atomic.Uint64
+	hookFailures atomic.Uint64
+	sampledOut   atomic.Uint64
+}
+
+// Stats returns a snapshot of the logger's counters
+func (l *Logger) Stats() Stats {
+	s := &l.core.stats
+	return Stats{
+		Written:      s.written.Load(),
+		Dropped:      s.dropped.Load(),
+		HookFailures: s.hookFailures.Load(),
+		SampledOut:   s.sampledOut.Load(),
+	}
+}
@@ -0,0 +1,201 @@
+package pdalog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// failingWriter fails every write
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+// failingHook fails its first failures fires
+type failingHook struct {
+	*MockHook
+	mu       sync.Mutex
+	failures int
+	attempts int
+}
+
+func (h *failingHook) Fire(entry map[string]interface{}) error {
+	h.mu.Lock()
+	h.attempts++
+	fail := h.attempts <= h.failures
+	h.mu.Unlock()
+	if fail {
+		return errors.New("publish timeout")
+	}
+	return h.MockHook.Fire(entry)
+}
+
+// errorRecorder collects errors passed to an ErrorHandler
+type errorRecorder struct {
+	mu     sync.Mutex
+	errors []error
+}
+
+func (r *errorRecorder) handle(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errors = append(r.errors, err)
+}
+
+func TestErrorHandlerWriteError(t *testing.T) {
+	recorder := &errorRecorder{}
+	log := New(Options{
+		Writer:       failingWriter{},
+		ErrorHandler: recorder.handle,
+	})
+
+	log.Info().Msg("lost")
+
+	if len(recorder.errors) != 1 {
+		t.Fatalf("Expected 1 reported error, got %d", len(recorder.errors))
+	}
+	var writeErr *WriteError
+	if !errors.As(recorder.errors[0], &writeErr) {
+		t.Fatalf("Expected a *WriteError, got %T", recorder.errors[0])
+	}
+	if _, ok := writeErr.Writer.(failingWriter); !ok {
+		t.Errorf("Expected the failing writer to be identified, got %T", writeErr.Writer)
+	}
+	if !bytes.Contains(writeErr.Entry, []byte(`"message":"lost"`)) {
+		t.Errorf("Expected the failed entry to be included, got %s", writeErr.Entry)
+	}
+
+	stats := log.Stats()
+	if stats.Written != 0 || stats.Dropped != 1 {
+		t.Errorf("Expected 0 written and 1 dropped, got %+v", stats)
+	}
+}
+
+func TestErrorHandlerHookError(t *testing.T) {
+	recorder := &errorRecorder{}
+	log := New(Options{
+		Writer:       &bytes.Buffer{},
+		ErrorHandler: recorder.handle,
+	})
+	hook := &failingHook{MockHook: NewMockHook(), failures: 1}
+	child := log.With("component", "api")
+	child.AddHook(hook)
+
+	child.Info().Msg("first")
+	child.Info().Msg("second")
+
+	if len(recorder.errors) != 1 {
+		t.Fatalf("Expected 1 reported error, got %d", len(recorder.errors))
+	}
+	var hookErr *HookError
+	if !errors.As(recorder.errors[0], &hookErr) {
+		t.Fatalf("Expected a *HookError, got %T", recorder.errors[0])
+	}
+	if hookErr.Hook != hook {
+		t.Error("Expected the failing hook to be identified")
+	}
+	if hookErr.Entry["message"] != "first" {
+		t.Errorf("Expected the failed entry to be included, got %v", hookErr.Entry)
+	}
+
+	// Counters are shared by the whole logger tree
+	stats := log.Stats()
+	if stats.Written != 2 || stats.Dropped != 0 || stats.HookFailures != 1 {
+		t.Errorf("Expected 2 written and 1 hook failure, got %+v", stats)
+	}
+}
+
+func TestErrorHandlerMarshalError(t *testing.T) {
+	recorder := &errorRecorder{}
+	buf := &bytes.Buffer{}
+	log := New(Options{
+		Outputs: []Output{
+			{Writer: buf},
+			{Writer: &bytes.Buffer{}, Encoder: LogfmtEncoder{}},
+		},
+		ErrorHandler: recorder.handle,
+	})
+
+	log.With("callback", func() {}).Info().Any("ch", make(chan int)).Msg("unmarshalable")
+
+	if len(recorder.errors) != 2 {
+		t.Fatalf("Expected 1 reported error per field, got %v", recorder.errors)
+	}
+	for i, key := range []string{"callback", "ch"} {
+		var marshalErr *MarshalError
+		if !errors.As(recorder.errors[i], &marshalErr) {
+			t.Fatalf("Expected a *MarshalError, got %T", recorder.errors[i])
+		}
+		if marshalErr.Key != key {
+			t.Errorf("Expected the field %q to be identified, got %q", key, marshalErr.Key)
+		}
+	}
+	if !bytes.Contains(buf.Bytes(), []byte(`"ch":"marshaling error: `)) {
+		t.Errorf("Expected a placeholder for the field, got %s", buf.Bytes())
+	}
+}
+
+func TestRetryHook(t *testing.T) {
+	inner := &failingHook{MockHook: NewMockHook(), failures: 2}
+	hook := NewRetryHook(inner, RetryOptions{
+		MaxRetries:     3,
+		InitialBackoff: time.Millisecond,
+	})
+
+	if err := hook.Fire(map[string]interface{}{"message": "m"}); err != nil {
+		t.Fatalf("Expected Fire to succeed after retries, got %v", err)
+	}
+	if inner.attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", inner.attempts)
+	}
+
+	inner = &failingHook{MockHook: NewMockHook(), failures: 10}
+	hook = NewRetryHook(inner, RetryOptions{
+		MaxRetries:     2,
+		InitialBackoff: time.Millisecond,
+	})
+	if err := hook.Fire(map[string]interface{}{"message": "m"}); err == nil {
+		t.Error("Expected Fire to fail once retries are exhausted")
+	}
+	if inner.attempts != 3 {
+		t.Errorf("Expected 1 attempt and 2 retries, got %d attempts", inner.attempts)
+	}
+}
+
+func TestRetryHookStopsWhenContextDone(t *testing.T) {
+	inner := &failingHook{MockHook: NewMockHook(), failures: 10}
+	hook := NewRetryHook(inner, RetryOptions{
+		MaxRetries:     5,
+		InitialBackoff: time.Hour,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := hook.FireContext(ctx, map[string]interface{}{}); err == nil {
+		t.Error("Expected the last error to be returned")
+	}
+	if inner.attempts != 1 {
+		t.Errorf("Expected retries to stop with the context, got %d attempts", inner.attempts)
+	}
+}
+
+func TestAsyncHookErrorHandler(t *testing.T) {
+	recorder := &errorRecorder{}
+	inner := &failingHook{MockHook: NewMockHook(), failures: 1}
+	hook := NewAsyncHook(inner, AsyncOptions{ErrorHandler: recorder.handle})
+
+	_ = hook.Fire(map[string]interface{}{"message": "m"})
+	if err := hook.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	var hookErr *HookError
+	if len(recorder.errors) != 1 || !errors.As(recorder.errors[0], &hookErr) || hookErr.Hook != inner {
+		t.Errorf("Expected a *HookError for the wrapped hook, got %v", recorder.errors)
+	}
+}
@@ -1,18 +1,83 @@
 package pdalog
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"os"
+	"sync"
 	"time"
 )
 
-// Event represents a log event
+// maxPooledBufferSize caps the buffers returned to the pool so that one huge
+// event does not keep a large allocation alive forever
+const maxPooledBufferSize = 64 << 10
+
+// fatalSyncTimeout bounds how long a Fatal event waits for writers and hooks
+// to flush before exiting
+const fatalSyncTimeout = 5 * time.Second
+
+var eventPool = sync.Pool{
+	New: func() interface{} {
+		e := &Event{line: make([]byte, 0, 512)}
+		e.inline[0] = make([]byte, 0, 512)
+		e.bufs = e.inline[:]
+		return e
+	},
+}
+
+// Event represents a log event. Events are pooled: an Event must not be kept
+// or reused after Msg, which returns it to the pool.
 type Event struct {
 	logger *Logger
-	level  Level
+	// encs are the encoders of the logger's outputs, bufs[i] is encoded with encs[i]
+	encs  []Encoder
+	level Level
+	// bufs hold the encoded context and event fields in insertion order
+	bufs [][]byte
+	// inline backs bufs for the common case of a single encoder
+	inline [1][]byte
+	// line is the scratch buffer the complete log line is assembled in
+	line []byte
+	time time.Time
+	ctx  context.Context
+	// fields mirrors the encoded fields as Go values and is only populated
+	// when at least one hook will receive the entry
 	fields map[string]interface{}
-	time   time.Time
+}
+
+// newPooledEvent takes an Event from the pool and prepares it for use
+func newPooledEvent(l *Logger, level Level) *Event {
+	e := eventPool.Get().(*Event)
+	e.logger = l
+	e.encs = l.core.encoders
+	e.level = level
+	if cap(e.bufs) < len(e.encs) {
+		e.bufs = append(e.bufs[:cap(e.bufs)], make([][]byte, len(e.encs)-cap(e.bufs))...)
+	}
+	e.bufs = e.bufs[:len(e.encs)]
+	for i := range e.bufs {
+		e.bufs[i] = e.bufs[i][:0]
+	}
+	e.line = e.line[:0]
+	e.time = timeNow()
+	return e
+}
+
+// putEvent returns the Event to the pool
+func putEvent(e *Event) {
+	// Cleared even when the event is not pooled, so a reused event does nothing
+	e.logger = nil
+	e.encs = nil
+	e.ctx = nil
+	e.fields = nil
+	if cap(e.line) > maxPooledBufferSize {
+		return
+	}
+	for _, buf := range e.bufs {
+		if cap(buf) > maxPooledBufferSize {
+			return
+		}
+	}
+	eventPool.Put(e)
 }
 
 // Str adds a string field to the event
@@ -20,7 +85,12 @@ func (e *Event) Str(key, val string) *Event {
 	if e == nil {
 		return nil
 	}
-	e.fields[key] = val
+	for i, enc := range e.encs {
+		e.bufs[i] = enc.AppendString(enc.AppendKey(e.bufs[i], key), val)
+	}
+	if e.fields != nil {
+		e.fields[key] = val
+	}
 	return e
 }
 
@@ -29,7 +99,12 @@ func (e *Event) Int(key string, val int) *Event {
 	if e == nil {
 		return nil
 	}
-	e.fields[key] = val
+	for i, enc := range e.encs {
+		e.bufs[i] = enc.AppendInt(enc.AppendKey(e.bufs[i], key), int64(val))
+	}
+	if e.fields != nil {
+		e.fields[key] = val
+	}
 	return e
 }
 
@@ -38,7 +113,12 @@ func (e *Event) Bool(key string, val bool) *Event {
 	if e == nil {
 		return nil
 	}
-	e.fields[key] = val
+	for i, enc := range e.encs {
+		e.bufs[i] = enc.AppendBool(enc.AppendKey(e.bufs[i], key), val)
+	}
+	if e.fields != nil {
+		e.fields[key] = val
+	}
 	return e
 }
 
@@ -50,8 +130,7 @@ func (e *Event) Err(err error) *Event {
 	if err == nil {
 		return e
 	}
-	e.fields["error"] = err.Error()
-	return e
+	return e.Str("error", err.Error())
 }
 
 // Any adds a field with any value to the event
@@ -59,7 +138,19 @@ func (e *Event) Any(key string, val interface{}) *Event {
 	if e == nil {
 		return nil
 	}
-	e.fields[key] = val
+	var marshalErr error
+	for i, enc := range e.encs {
+		var err error
+		if e.bufs[i], err = appendInterface(enc, enc.AppendKey(e.bufs[i], key), val); err != nil {
+			marshalErr = err
+		}
+	}
+	if marshalErr != nil {
+		e.logger.core.errorHandler(&MarshalError{Key: key, Value: val, Err: marshalErr})
+	}
+	if e.fields != nil {
+		e.fields[key] = val
+	}
 	return e
 }
 
@@ -68,7 +159,12 @@ func (e *Event) Duration(key string, val time.Duration) *Event {
 	if e == nil {
 		return nil
 	}
-	e.fields[key] = val
+	for i, enc := range e.encs {
+		e.bufs[i] = enc.AppendDuration(enc.AppendKey(e.bufs[i], key), val)
+	}
+	if e.fields != nil {
+		e.fields[key] = val
+	}
 	return e
 }
 
@@ -77,7 +173,12 @@ func (e *Event) Time(key string, val time.Time) *Event {
 	if e == nil {
 		return nil
 	}
-	e.fields[key] = val
+	for i, enc := range e.encs {
+		e.bufs[i] = enc.AppendTime(enc.AppendKey(e.bufs[i], key), val, time.RFC3339Nano)
+	}
+	if e.fields != nil {
+		e.fields[key] = val
+	}
 	return e
 }
 
@@ -86,65 +187,76 @@ func (e *Event) Hex(key string, val []byte) *Event {
 	if e == nil {
 		return nil
 	}
-	e.fields[key] = fmt.Sprintf("%x", val)
+	for i, enc := range e.encs {
+		e.bufs[i] = enc.AppendHex(enc.AppendKey(e.bufs[i], key), val)
+	}
+	if e.fields != nil {
+		e.fields[key] = fmt.Sprintf("%x", val)
+	}
 	return e
 }
 
-// Msg sends the event with the given message
+// Msg sends the event with the given message and returns the event to the
+// pool. The event must not be used afterwards; calling Msg again on an event
+// that is still in the pool does nothing.
 func (e *Event) Msg(msg string) {
-	if e == nil {
+	if e == nil || e.logger == nil {
 		return
 	}
+	defer putEvent(e)
 
-	// Create the log entry
-	entry := map[string]interface{}{
-		"level":   e.level.String(),
-		"time":    e.time.Format(e.logger.timeFormat),
-		"message": msg,
-	}
+	// Each output receives the line rendered by its encoder; a failing
+	// output does not keep the line from the others
+	core := e.logger.core
+	for i, enc := range e.encs {
+		if !core.wantsEncoder(i, e.level) {
+			continue
+		}
 
-	// Add all fields
-	for k, v := range e.fields {
-		entry[k] = v
-	}
+		// time, level and message always lead, followed by the fields in the
+		// order they were added
+		e.line = enc.AppendBeginMarker(e.line[:0])
+		e.line = enc.AppendTime(enc.AppendKey(e.line, "time"), e.time, core.timeFormat)
+		e.line = enc.AppendString(enc.AppendKey(e.line, "level"), e.level.String())
+		e.line = enc.AppendString(enc.AppendKey(e.line, "message"), msg)
+		e.line = enc.AppendFields(e.line, e.bufs[i])
+		e.line = enc.AppendLineBreak(enc.AppendEndMarker(e.line))
 
-	// Marshal to JSON
-	jsonData, err := json.Marshal(entry)
-	if err != nil {
-		_, _ = fmt.Fprintf(os.Stderr, "Error marshaling log entry: %v\n", err)
-		return
+		for _, out := range core.outputs {
+			if out.encoder == i && e.level >= out.level {
+				core.write(out, e.line)
+			}
+		}
 	}
 
-	// Write to output
-	e.logger.mu.Lock()
-	defer e.logger.mu.Unlock()
-
-	jsonData = append(jsonData, '\n')
-	_, err = e.logger.writer.Write(jsonData)
-	if err != nil {
-		_, _ = fmt.Fprintf(os.Stderr, "Error writing log entry: %v\n", err)
+	// Fire hooks outside the write lock, so a slow hook only delays its own caller
+	if e.fields != nil {
+		e.fields["level"] = e.level.String()
+		e.fields["time"] = e.time.Format(core.timeFormat)
+		e.fields["message"] = msg
+		e.logger.fireHooks(e.GetCtx(), e.level, e.fields)
 	}
 
-	// Fire hooks
-	for _, hook := range e.logger.hooks {
-		// Check if this hook should be triggered for this level
-		shouldFire := false
-		for _, level := range hook.Levels() {
-			if level == e.level {
-				shouldFire = true
-				break
-			}
-		}
-
-		if shouldFire {
-			if err := hook.Fire(entry); err != nil {
-				_, _ = fmt.Fprintf(os.Stderr, "Error firing hook: %v\n", err)
-			}
+	// If fatal, flush and exit the program; if panic, panic with the message
+	switch e.level {
+	case FatalLevel:
+		ctx, cancel := context.WithTimeout(context.Background(), fatalSyncTimeout)
+		if err := e.logger.sync(ctx); err != nil {
+			core.errorHandler(&SyncError{Err: err})
 		}
+		cancel()
+		core.exitFunc(1)
+	case PanicLevel:
+		panic(msg)
 	}
+}
 
-	// If fatal, exit the program
-	if e.level == FatalLevel {
-		os.Exit(1)
+// hookFiresFor reports whether the hook should be triggered for the level
+func hookFiresFor(hook Hook, level Level) bool {
+	for _, l := range hook.Levels() {
+		if l == level {
+			return true
+		}
 	}
+	return false
 }
@@ -3,6 +3,8 @@ package pdalog
 import (
 	"bytes"
 	"encoding/json"
+	"io"
+	"strings"
 	"testing"
 	"time"
 )
@@ -104,3 +106,68 @@ func TestHexField(t *testing.T) {
 		t.Error("Expected nil.Hex to return nil")
 	}
 }
+
+func TestEventReusedAfterMsg(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := New(Options{Writer: buf, Level: DebugLevel})
+
+	event := log.Info().Str("key", "value")
+	event.Msg("first")
+	event.Str("other", "value").Msg("second")
+	if lines := strings.Count(buf.String(), "\n"); lines != 1 || !strings.Contains(buf.String(), "first") {
+		t.Errorf("Expected only the first line, got %q", buf.String())
+	}
+
+	// An event too large to be pooled is cleared as well
+	event = log.Info().Str("big", strings.Repeat("x", maxPooledBufferSize+1))
+	event.Msg("big")
+	event.Msg("again")
+	if strings.Contains(buf.String(), "again") {
+		t.Errorf("Expected the reused event to do nothing, got %q", buf.String())
+	}
+}
+
+func TestEventZeroAllocs(t *testing.T) {
+	log := New(Options{
+		Writer: io.Discard,
+		Level:  DebugLevel,
+	}).With("request_id", "req-123456")
+
+	data := []byte{0xDE, 0xAD, 0xBE, 0xEF}
+	now := time.Now()
+
+	allocs := testing.AllocsPerRun(100, func() {
+		log.Info().
+			Str("service", "api").
+			Int("status", 200).
+			Bool("cache_hit", true).
+			Duration("elapsed", 150*time.Millisecond).
+			Time("started", now).
+			Hex("signature", data).
+			Msg("request completed")
+	})
+	if allocs != 0 {
+		t.Errorf("Expected no allocations per logged line, got %v", allocs)
+	}
+}
+
+func TestStringEscaping(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := New(Options{
+		Writer: buf,
+		Level:  DebugLevel,
+	})
+
+	value := "quote\" backslash\\ newline\n tab\t bell\x07 invalid\xff unicode ž"
+	log.Info().Str("value", value).Msg("escaping")
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Failed to parse JSON: %v (%s)", err, buf.String())
+	}
+
+	expected := strings.ToValidUTF8(value, "�")
+	if entry["value"] != expected {
+		t.Errorf("Expected value to be %q, got %q", expected, entry["value"])
+	}
+}
@@ -0,0 +1,217 @@
+package pdalog
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileFormat selects how FileHook renders entries
+type FileFormat int
+
+const (
+	// FileFormatJSON writes one JSON object per line
+	FileFormatJSON FileFormat = iota
+	// FileFormatLogfmt writes logfmt lines, see LogfmtEncoder
+	FileFormatLogfmt
+	// FileFormatText writes uncolored ConsoleWriter lines with full timestamps
+	FileFormatText
+)
+
+// levelPlaceholder is replaced with the entry's level in FileHookOptions.Path
+const levelPlaceholder = "{level}"
+
+// FileHookOptions configures a FileHook
+type FileHookOptions struct {
+	// Path is the file entries are written to. {level} is replaced with the
+	// entry's level name, e.g. "logs/{level}.log" writes errors to logs/error.log.
+	// Parent directories are created.
+	Path string
+	// Levels are the levels the hook fires for, all levels known when the hook
+	// is created when empty
+	Levels []Level
+	// Format is the rendering of the written lines
+	Format FileFormat
+	// Rotation enables rotation of every file, its Filename is ignored
+	Rotation *RotatingFileOptions
+	// FileMode is used when creating files, 0644 when zero
+	FileMode os.FileMode
+}
+
+// fileHookOutput is an open file of a FileHook
+type fileHookOutput struct {
+	file io.WriteCloser
+	out  io.Writer
+}
+
+// FileHook writes entries to files, optionally one file per level. Unlike
+// the logger's writer it has its own format and level filter, e.g. to keep
+// errors in a separate file. All entry fields are written: time, level and
+// message first, followed by the remaining fields sorted by key.
+type FileHook struct {
+	opts FileHookOptions
+	enc  Encoder
+
+	// mu guards files and closed and serializes writes
+	mu     sync.Mutex
+	files  map[string]*fileHookOutput
+	closed bool
+}
+
+// NewFileHook creates a file hook. Files are opened when the first entry for
+// them is fired, so errors opening them are reported through the logger's
+// ErrorHandler.
+func NewFileHook(opts FileHookOptions) (*FileHook, error) {
+	if opts.Path == "" {
+		return nil, errors.New("file hook needs a path")
+	}
+	if len(opts.Levels) == 0 {
+		opts.Levels = AllLevels()
+	}
+	if opts.FileMode == 0 {
+		opts.FileMode = 0644
+	}
+
+	h := &FileHook{
+		opts:  opts,
+		enc:   JSONEncoder{},
+		files: make(map[string]*fileHookOutput),
+	}
+	if opts.Format == FileFormatLogfmt {
+		h.enc = LogfmtEncoder{}
+	}
+	return h, nil
+}
+
+// Fire writes the entry to the file for its level
+func (h *FileHook) Fire(entry map[string]interface{}) error {
+	line := appendEntry(h.enc, nil, entry)
+	level, _ := entry["level"].(string)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return ErrHookClosed
+	}
+	output, err := h.output(h.path(level))
+	if err != nil {
+		return err
+	}
+	_, err = output.out.Write(line)
+	return err
+}
+
+// Levels returns the log levels this hook should be triggered for
+func (h *FileHook) Levels() []Level {
+	return h.opts.Levels
+}
+
+// Flush commits the open files to stable storage
+func (h *FileHook) Flush(ctx context.Context) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var err error
+	for _, output := range h.files {
+		err = errors.Join(err, flush(ctx, output.file))
+	}
+	return err
+}
+
+// Close closes the open files. It is safe to call Close more than once.
+func (h *FileHook) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return nil
+	}
+	h.closed = true
+
+	var err error
+	for _, output := range h.files {
+		err = errors.Join(err, output.file.Close())
+	}
+	h.files = nil
+	return err
+}
+
+// path returns the file path for the level
+func (h *FileHook) path(level string) string {
+	if !strings.Contains(h.opts.Path, levelPlaceholder) {
+		return h.opts.Path
+	}
+	if level == "" {
+		level = "unknown"
+	}
+	// Registered level names must not escape the configured directory
+	level = strings.NewReplacer("/", "_", `\`, "_", "..", "_").Replace(level)
+	return strings.ReplaceAll(h.opts.Path, levelPlaceholder, level)
+}
+
+// output returns the open file for path, opening it if needed, h.mu must be held
+func (h *FileHook) output(path string) (*fileHookOutput, error) {
+	if output, ok := h.files[path]; ok {
+		return output, nil
+	}
+
+	var file io.WriteCloser
+	if h.opts.Rotation != nil {
+		rotation := *h.opts.Rotation
+		rotation.Filename = path
+		if rotation.FileMode == 0 {
+			rotation.FileMode = h.opts.FileMode
+		}
+		rf, err := NewRotatingFile(rotation)
+		if err != nil {
+			return nil, err
+		}
+		file = rf
+	} else {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, err
+		}
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, h.opts.FileMode)
+		if err != nil {
+			return nil, err
+		}
+		file = f
+	}
+
+	output := &fileHookOutput{file: file, out: file}
+	if h.opts.Format == FileFormatText {
+		output.out = &ConsoleWriter{Out: file, NoColor: true, TimeFormat: time.RFC3339Nano}
+	}
+	h.files[path] = output
+	return output, nil
+}
+
+// appendEntry encodes a hook entry as a log line: time, level and message
+// first, followed by the remaining fields sorted by key
+func appendEntry(enc Encoder, dst []byte, entry map[string]interface{}) []byte {
+	dst = enc.AppendBeginMarker(dst)
+	for _, key := range []string{"time", "level", "message"} {
+		if val, ok := entry[key]; ok {
+			dst = enc.AppendInterface(enc.AppendKey(dst, key), val)
+		}
+	}
+
+	keys := make([]string, 0, len(entry))
+	for key := range entry {
+		switch key {
+		case "time", "level", "message":
+		default:
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		dst = enc.AppendInterface(enc.AppendKey(dst, key), entry[key])
+	}
+	return enc.AppendLineBreak(enc.AppendEndMarker(dst))
+}
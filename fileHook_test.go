@@ -0,0 +1,145 @@
+package pdalog
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileHookPerLevelFiles(t *testing.T) {
+	dir := t.TempDir()
+	hook, err := NewFileHook(FileHookOptions{
+		Path:   filepath.Join(dir, "logs", "{level}.log"),
+		Levels: []Level{WarnLevel, ErrorLevel},
+	})
+	if err != nil {
+		t.Fatalf("NewFileHook returned error: %v", err)
+	}
+
+	log := New(Options{Writer: &bytes.Buffer{}})
+	log.AddHook(hook)
+	log.Info().Msg("not written")
+	log.Warn().Str("disk", "sda").Msg("disk almost full")
+	log.Error().Err(errors.New("timeout")).Int("attempt", 3).Duration("elapsed", time.Second).Msg("request failed")
+	if err := log.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(dir, "logs"))
+	if err != nil {
+		t.Fatalf("ReadDir returned error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 files, got %d", len(entries))
+	}
+
+	warn, _ := os.ReadFile(filepath.Join(dir, "logs", "warn.log"))
+	if !strings.Contains(string(warn), `"level":"warn","message":"disk almost full","disk":"sda"}`) {
+		t.Errorf("Unexpected warn file content %q", warn)
+	}
+
+	errorLine, _ := os.ReadFile(filepath.Join(dir, "logs", "error.log"))
+	expected := `"level":"error","message":"request failed","attempt":3,"elapsed":1000000000,"error":"timeout"}` + "\n"
+	if !strings.HasPrefix(string(errorLine), `{"time":"`) || !strings.HasSuffix(string(errorLine), expected) {
+		t.Errorf("Unexpected error file content %q", errorLine)
+	}
+}
+
+func TestFileHookFormats(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   FileFormat
+		expected string
+	}{
+		{"logfmt", FileFormatLogfmt, "time=2025-08-04T21:02:00Z level=info message=\"cache warmed\" entries=1024 name=users\n"},
+		{"text", FileFormatText, "2025-08-04T21:02:00Z INF cache warmed entries=1024 name=users\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "app.log")
+			hook, err := NewFileHook(FileHookOptions{Path: path, Format: tt.format})
+			if err != nil {
+				t.Fatalf("NewFileHook returned error: %v", err)
+			}
+			defer hook.Close()
+
+			err = hook.Fire(map[string]interface{}{
+				"time":    "2025-08-04T21:02:00Z",
+				"level":   "info",
+				"message": "cache warmed",
+				"name":    "users",
+				"entries": 1024,
+			})
+			if err != nil {
+				t.Fatalf("Fire returned error: %v", err)
+			}
+
+			data, _ := os.ReadFile(path)
+			if string(data) != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, data)
+			}
+		})
+	}
+}
+
+func TestFileHookDefaultsAndClose(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := NewFileHook(FileHookOptions{}); err == nil {
+		t.Error("Expected an error without a path")
+	}
+
+	hook, err := NewFileHook(FileHookOptions{Path: filepath.Join(dir, "{level}.log")})
+	if err != nil {
+		t.Fatalf("NewFileHook returned error: %v", err)
+	}
+	if len(hook.Levels()) != len(AllLevels()) {
+		t.Errorf("Expected the hook to fire for all levels, got %v", hook.Levels())
+	}
+
+	// Level names cannot escape the directory
+	if err := hook.Fire(map[string]interface{}{"level": "../evil"}); err != nil {
+		t.Fatalf("Fire returned error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "__evil.log")); err != nil {
+		t.Errorf("Expected the sanitized file to exist: %v", err)
+	}
+
+	if err := hook.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	if err := hook.Close(); err != nil {
+		t.Errorf("Second Close returned error: %v", err)
+	}
+	if err := hook.Fire(map[string]interface{}{"level": "info"}); !errors.Is(err, ErrHookClosed) {
+		t.Errorf("Expected ErrHookClosed, got %v", err)
+	}
+}
+
+func TestFileHookRotation(t *testing.T) {
+	dir := t.TempDir()
+	hook, err := NewFileHook(FileHookOptions{
+		Path:     filepath.Join(dir, "error.log"),
+		Rotation: &RotatingFileOptions{MaxSize: 64},
+	})
+	if err != nil {
+		t.Fatalf("NewFileHook returned error: %v", err)
+	}
+	defer hook.Close()
+
+	for i := 0; i < 3; i++ {
+		if err := hook.Fire(map[string]interface{}{"level": "error", "message": strings.Repeat("x", 40)}); err != nil {
+			t.Fatalf("Fire returned error: %v", err)
+		}
+		time.Sleep(2 * time.Millisecond) // backup names have millisecond precision
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 3 {
+		t.Errorf("Expected the active file and 2 backups, got %d files", len(entries))
+	}
+}
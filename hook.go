@@ -1,5 +1,11 @@
 package pdalog
 
+import (
+	"context"
+	"io"
+	"os"
+)
+
 // Hook represents a log hook that processes log entries
 type Hook interface {
 	// Fire is called when a log event occurs
@@ -7,3 +13,44 @@ type Hook interface {
 	// Levels returns the log levels this hook should be triggered for
 	Levels() []Level
 }
+
+// ContextHook is an optional interface for hooks that need the context
+// attached to an event with Event.Ctx, e.g. to record entries on the active
+// trace span. When a hook implements it, FireContext is called instead of Fire.
+type ContextHook interface {
+	Hook
+	// FireContext is called when a log event occurs, with the event's context
+	FireContext(ctx context.Context, entry map[string]interface{}) error
+}
+
+// Flusher is implemented by writers and hooks that buffer entries, such as
+// AsyncHook. Logger.Sync and Logger.Close call Flush before returning, and
+// Fatal events call it before exiting. Writers and hooks with a plain
+// Flush() error or Sync() error method, like bufio.Writer or os.File, are
+// flushed as well.
+type Flusher interface {
+	// Flush delivers buffered entries, giving up when ctx is done
+	Flush(ctx context.Context) error
+}
+
+// flush flushes v if it buffers entries
+func flush(ctx context.Context, v interface{}) error {
+	switch f := v.(type) {
+	case Flusher:
+		return f.Flush(ctx)
+	case interface{ Flush() error }:
+		return f.Flush()
+	case interface{ Sync() error }:
+		if w, ok := v.(io.Writer); ok && isStdStream(w) {
+			// Syncing a terminal or pipe fails on some platforms
+			return nil
+		}
+		return f.Sync()
+	}
+	return nil
+}
+
+// isStdStream reports whether w is the process's standard output or error
+func isStdStream(w io.Writer) bool {
+	return w == os.Stdout || w == os.Stderr
+}
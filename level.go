@@ -1,51 +1,194 @@
 package pdalog
 
-// Level represents logging level
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Level represents logging level. Built-in levels are spaced apart so that
+// custom levels registered with RegisterLevel can be placed between them.
 type Level int8
 
 const (
+	// TraceLevel defines trace log level, for very chatty output such as protocol dumps
+	TraceLevel Level = -10
 	// DebugLevel defines debug log level
-	DebugLevel Level = iota
+	DebugLevel Level = 0
 	// InfoLevel defines info log level
-	InfoLevel
+	InfoLevel Level = 10
 	// WarnLevel defines warn log level
-	WarnLevel
+	WarnLevel Level = 20
 	// ErrorLevel defines error log level
-	ErrorLevel
-	// FatalLevel defines fatal log level
-	FatalLevel
+	ErrorLevel Level = 30
+	// FatalLevel defines fatal log level, the program exits after logging
+	FatalLevel Level = 40
+	// PanicLevel defines panic log level, the message is logged and then panicked with
+	PanicLevel Level = 50
 )
 
-var levelNames = map[Level]string{
-	DebugLevel: "debug",
-	InfoLevel:  "info",
-	WarnLevel:  "warn",
-	ErrorLevel: "error",
-	FatalLevel: "fatal",
+var (
+	levelsMu   sync.RWMutex
+	levelNames = map[Level]string{
+		TraceLevel: "trace",
+		DebugLevel: "debug",
+		InfoLevel:  "info",
+		WarnLevel:  "warn",
+		ErrorLevel: "error",
+		FatalLevel: "fatal",
+		PanicLevel: "panic",
+	}
+	levelValues = map[string]Level{
+		"trace": TraceLevel,
+		"debug": DebugLevel,
+		"info":  InfoLevel,
+		"warn":  WarnLevel,
+		"error": ErrorLevel,
+		"fatal": FatalLevel,
+		"panic": PanicLevel,
+	}
+	// levelAliases maps common alternative spellings to level names
+	levelAliases = map[string]string{
+		"trc":         "trace",
+		"dbg":         "debug",
+		"information": "info",
+		"inf":         "info",
+		"warning":     "warn",
+		"wrn":         "warn",
+		"err":         "error",
+		"crit":        "fatal",
+		"critical":    "fatal",
+		"ftl":         "fatal",
+	}
+)
+
+// ErrUnknownLevel is returned when a string does not name a known level
+var ErrUnknownLevel = errors.New("unknown level")
+
+// RegisterLevel defines a custom level with the given name and severity.
+// The returned Level works with ParseLevel, Level.String, hook filtering and
+// level thresholds like the built-in levels. Names are matched
+// case-insensitively. Levels should be registered during initialization,
+// before loggers and hooks are created.
+func RegisterLevel(name string, severity int8) (Level, error) {
+	if name == "" {
+		return 0, errors.New("level name must not be empty")
+	}
+
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+
+	level := Level(severity)
+	key := strings.ToLower(name)
+	if existing, ok := levelNames[level]; ok {
+		return 0, fmt.Errorf("severity %d is already used by level %q", severity, existing)
+	}
+	if _, ok := levelValues[key]; ok {
+		return 0, fmt.Errorf("level %q is already registered", name)
+	}
+	if _, ok := levelAliases[key]; ok {
+		return 0, fmt.Errorf("level %q is already an alias", name)
+	}
+
+	levelNames[level] = name
+	levelValues[key] = level
+	return level, nil
+}
+
+// AllLevels returns every built-in and registered level ordered by severity
+func AllLevels() []Level {
+	levelsMu.RLock()
+	levels := make([]Level, 0, len(levelNames))
+	for level := range levelNames {
+		levels = append(levels, level)
+	}
+	levelsMu.RUnlock()
+
+	sort.Slice(levels, func(i, j int) bool { return levels[i] < levels[j] })
+	return levels
 }
 
 // String returns the string representation of the log level
 func (l Level) String() string {
-	if name, ok := levelNames[l]; ok {
+	// Built-in levels are resolved without taking the registry lock
+	switch l {
+	case TraceLevel:
+		return "trace"
+	case DebugLevel:
+		return "debug"
+	case InfoLevel:
+		return "info"
+	case WarnLevel:
+		return "warn"
+	case ErrorLevel:
+		return "error"
+	case FatalLevel:
+		return "fatal"
+	case PanicLevel:
+		return "panic"
+	}
+
+	levelsMu.RLock()
+	name, ok := levelNames[l]
+	levelsMu.RUnlock()
+	if ok {
 		return name
 	}
 	return "unknown"
 }
 
-// ParseLevel parses a level string into a Level value
+// ParseLevel parses a level string into a Level value, returning InfoLevel
+// for unknown input. Use ParseLevelStrict to detect typos.
 func ParseLevel(levelStr string) Level {
-	switch levelStr {
-	case "debug":
-		return DebugLevel
-	case "info":
-		return InfoLevel
-	case "warn":
-		return WarnLevel
-	case "error":
-		return ErrorLevel
-	case "fatal":
-		return FatalLevel
-	default:
+	level, err := ParseLevelStrict(levelStr)
+	if err != nil {
 		return InfoLevel // Default is info
 	}
+	return level
+}
+
+// ParseLevelStrict parses a level string into a Level value. Matching is
+// case-insensitive and accepts common aliases such as "warning", "err" and
+// "crit". Unknown input returns an error wrapping ErrUnknownLevel.
+func ParseLevelStrict(levelStr string) (Level, error) {
+	key := strings.ToLower(strings.TrimSpace(levelStr))
+	if alias, ok := levelAliases[key]; ok {
+		key = alias
+	}
+
+	levelsMu.RLock()
+	level, ok := levelValues[key]
+	levelsMu.RUnlock()
+	if !ok {
+		return InfoLevel, fmt.Errorf("%w: %q", ErrUnknownLevel, levelStr)
+	}
+	return level, nil
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (l Level) MarshalText() ([]byte, error) {
+	levelsMu.RLock()
+	name, ok := levelNames[l]
+	levelsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownLevel, int8(l))
+	}
+	return []byte(name), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler using ParseLevelStrict
+func (l *Level) UnmarshalText(text []byte) error {
+	level, err := ParseLevelStrict(string(text))
+	if err != nil {
+		return err
+	}
+	*l = level
+	return nil
+}
+
+// Set implements flag.Value, so a Level can be used with flag.Var
+func (l *Level) Set(value string) error {
+	return l.UnmarshalText([]byte(value))
 }
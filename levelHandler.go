@@ -0,0 +1,85 @@
+package pdalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// maxLevelRequestSize bounds the body of a PUT to a LevelHandler
+const maxLevelRequestSize = 64 << 10
+
+// LevelState is the JSON document served by LevelHandler, e.g.
+// {"level":"info","components":{"db":"debug","http.*":"warn"}}
+type LevelState struct {
+	// Level is the logger's level
+	Level *Level `json:"level,omitempty"`
+	// Components are the component level patterns, see Logger.SetComponentLevels
+	Components map[string]Level `json:"components,omitempty"`
+}
+
+// LevelHandler is an http.Handler to read and change the levels of a running
+// logger. GET returns the LevelState. PUT changes the fields present in the
+// request body: a level sets the logger's level, components replace all
+// component level patterns, and an empty components object removes them. The
+// new state is returned. Mount it on an internal or authenticated endpoint only.
+type LevelHandler struct {
+	logger *Logger
+}
+
+// NewLevelHandler creates a LevelHandler for the logger
+func NewLevelHandler(logger *Logger) *LevelHandler {
+	return &LevelHandler{logger: logger}
+}
+
+// ServeHTTP implements http.Handler
+func (h *LevelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+	case http.MethodPut:
+		if err := h.update(r.Body); err != nil {
+			writeLevelJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, HEAD, PUT")
+		writeLevelJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	writeLevelJSON(w, http.StatusOK, h.state())
+}
+
+// state returns the current levels
+func (h *LevelHandler) state() LevelState {
+	level := h.logger.GetLevel()
+	return LevelState{Level: &level, Components: h.logger.ComponentLevels()}
+}
+
+// update applies a PUT body, changing nothing if it is invalid
+func (h *LevelHandler) update(body io.Reader) error {
+	var req LevelState
+	dec := json.NewDecoder(io.LimitReader(body, maxLevelRequestSize))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		return fmt.Errorf("invalid request: %w", err)
+	}
+
+	// Components are validated when set, so they go first
+	if req.Components != nil {
+		if err := h.logger.SetComponentLevels(req.Components); err != nil {
+			return err
+		}
+	}
+	if req.Level != nil {
+		h.logger.SetLevel(*req.Level)
+	}
+	return nil
+}
+
+// writeLevelJSON writes v as the JSON response
+func writeLevelJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,79 @@
+package pdalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// serveLevel sends a request to the handler and decodes the JSON response
+func serveLevel(t *testing.T, h http.Handler, method, body string) (int, map[string]interface{}) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, "/log/level", strings.NewReader(body)))
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected a JSON response, got %q", ct)
+	}
+	var resp map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to parse response %q: %v", rec.Body.String(), err)
+	}
+	return rec.Code, resp
+}
+
+func TestLevelHandler(t *testing.T) {
+	log := New(Options{Writer: &bytes.Buffer{}, Level: InfoLevel})
+	h := NewLevelHandler(log)
+
+	code, resp := serveLevel(t, h, http.MethodGet, "")
+	if code != http.StatusOK || resp["level"] != "info" || resp["components"] != nil {
+		t.Errorf("Unexpected GET response %d %v", code, resp)
+	}
+
+	code, resp = serveLevel(t, h, http.MethodPut, `{"level":"debug","components":{"db":"trace","http.*":"warn"}}`)
+	if code != http.StatusOK || resp["level"] != "debug" {
+		t.Errorf("Unexpected PUT response %d %v", code, resp)
+	}
+	if log.GetLevel() != DebugLevel || log.Named("db").GetLevel() != TraceLevel || log.Named("http").Named("x").GetLevel() != WarnLevel {
+		t.Errorf("Levels were not applied: %v %v", log.GetLevel(), log.ComponentLevels())
+	}
+
+	// Fields missing from the body are kept
+	_, resp = serveLevel(t, h, http.MethodPut, `{"level":"warn"}`)
+	if components, ok := resp["components"].(map[string]interface{}); !ok || components["db"] != "trace" {
+		t.Errorf("Expected the components to be kept, got %v", resp)
+	}
+	_, resp = serveLevel(t, h, http.MethodPut, `{"components":{}}`)
+	if resp["level"] != "warn" || resp["components"] != nil || len(log.ComponentLevels()) != 0 {
+		t.Errorf("Expected the components to be removed, got %v", resp)
+	}
+}
+
+func TestLevelHandlerErrors(t *testing.T) {
+	log := New(Options{Writer: &bytes.Buffer{}, Level: InfoLevel})
+	h := NewLevelHandler(log)
+
+	for _, body := range []string{
+		`{"level":"verbose"}`,
+		`{"lvl":"debug"}`,
+		`{"level":"debug","components":{"db[":"debug"}}`,
+		`not json`,
+	} {
+		code, resp := serveLevel(t, h, http.MethodPut, body)
+		if code != http.StatusBadRequest || resp["error"] == nil {
+			t.Errorf("PUT %s: unexpected response %d %v", body, code, resp)
+		}
+	}
+	if log.GetLevel() != InfoLevel {
+		t.Errorf("Expected invalid requests to change nothing, got %v", log.GetLevel())
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/log/level", nil))
+	if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") == "" {
+		t.Errorf("Unexpected POST response %d %v", rec.Code, rec.Header())
+	}
+}
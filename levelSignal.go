@@ -0,0 +1,67 @@
+//go:build !windows
+
+package pdalog
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// ToggleLevelOnSignal sets the logger's level to level, typically
+// DebugLevel, when the process receives SIGUSR1 and reverts to the previous
+// level on SIGUSR2, e.g. `kill -USR1 <pid>` to raise the verbosity of a live
+// process. A child logger that followed its parent's level follows it again
+// after the revert. Named loggers matching a component level pattern keep
+// their level. Call the returned function to stop handling the signals.
+//
+// SIGUSR2 only reverts while the level is still the one set by SIGUSR1: a
+// level changed in between, e.g. through LevelHandler, is kept.
+func (l *Logger) ToggleLevelOnSignal(level Level) (stop func()) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1, syscall.SIGUSR2)
+	done := make(chan struct{})
+
+	var routines sync.WaitGroup
+	routines.Add(1)
+	go func() {
+		defer routines.Done()
+		var previous Level
+		toggled, inherited := false, false
+		for {
+			select {
+			case sig := <-signals:
+				switch {
+				case sig == syscall.SIGUSR1 && !toggled:
+					previous = l.GetLevel()
+					inherited = l.parent != nil && !l.levelSet.Load()
+					toggled = true
+					l.SetLevel(level)
+				case sig == syscall.SIGUSR2 && toggled:
+					toggled = false
+					if !l.levelSet.Load() || Level(l.level.Load()) != level {
+						// Changed since SIGUSR1, keep the new level
+						continue
+					}
+					if inherited {
+						l.InheritLevel()
+					} else {
+						l.SetLevel(previous)
+					}
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(signals)
+			close(done)
+			routines.Wait()
+		})
+	}
+}
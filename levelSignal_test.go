@@ -0,0 +1,83 @@
+//go:build !windows
+
+package pdalog
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// waitForLevel waits until the logger's level is the expected one
+func waitForLevel(t *testing.T, log *Logger, expected Level) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for log.GetLevel() != expected {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected level %v, got %v", expected, log.GetLevel())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestToggleLevelOnSignal(t *testing.T) {
+	log := New(Options{Writer: &bytes.Buffer{}, Level: WarnLevel})
+	stop := log.ToggleLevelOnSignal(DebugLevel)
+	defer stop()
+
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatalf("Failed to send SIGUSR1: %v", err)
+	}
+	waitForLevel(t, log, DebugLevel)
+
+	// A second SIGUSR1 keeps the level to revert to
+	_ = syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+	time.Sleep(20 * time.Millisecond)
+	_ = syscall.Kill(syscall.Getpid(), syscall.SIGUSR2)
+	waitForLevel(t, log, WarnLevel)
+
+	stop()
+	stop()
+}
+
+func TestToggleLevelOnSignalChildInheritsAfterRevert(t *testing.T) {
+	log := New(Options{Writer: &bytes.Buffer{}, Level: WarnLevel})
+	child := log.With("component", "db")
+	stop := child.ToggleLevelOnSignal(DebugLevel)
+	defer stop()
+
+	_ = syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+	waitForLevel(t, child, DebugLevel)
+	_ = syscall.Kill(syscall.Getpid(), syscall.SIGUSR2)
+	waitForLevel(t, child, WarnLevel)
+
+	log.SetLevel(ErrorLevel)
+	if child.GetLevel() != ErrorLevel {
+		t.Errorf("Expected the child to follow its parent after the revert, got %v", child.GetLevel())
+	}
+}
+
+func TestToggleLevelOnSignalKeepsLevelChangedInBetween(t *testing.T) {
+	log := New(Options{Writer: &bytes.Buffer{}, Level: InfoLevel})
+	stop := log.ToggleLevelOnSignal(DebugLevel)
+	defer stop()
+
+	_ = syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+	waitForLevel(t, log, DebugLevel)
+
+	rec := httptest.NewRecorder()
+	NewLevelHandler(log).ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"level":"warn"}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Unexpected PUT response %d", rec.Code)
+	}
+
+	_ = syscall.Kill(syscall.Getpid(), syscall.SIGUSR2)
+	time.Sleep(50 * time.Millisecond)
+	if log.GetLevel() != WarnLevel {
+		t.Errorf("Expected the level set through the handler to be kept, got %v", log.GetLevel())
+	}
+}
@@ -0,0 +1,9 @@
+//go:build windows
+
+package pdalog
+
+// ToggleLevelOnSignal does nothing on Windows, which has no SIGUSR1 and
+// SIGUSR2; use LevelHandler to change the level of a running process
+func (l *Logger) ToggleLevelOnSignal(level Level) (stop func()) {
+	return func() {}
+}
@@ -0,0 +1,111 @@
+package pdalog
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"testing"
+)
+
+func TestParseLevelStrict(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Level
+	}{
+		{"debug", DebugLevel},
+		{"DEBUG", DebugLevel},
+		{" Info ", InfoLevel},
+		{"warning", WarnLevel},
+		{"WARN", WarnLevel},
+		{"err", ErrorLevel},
+		{"crit", FatalLevel},
+		{"critical", FatalLevel},
+		{"Trace", TraceLevel},
+	}
+
+	for _, test := range tests {
+		level, err := ParseLevelStrict(test.input)
+		if err != nil {
+			t.Errorf("ParseLevelStrict(%q) returned error: %v", test.input, err)
+			continue
+		}
+		if level != test.expected {
+			t.Errorf("ParseLevelStrict(%q) = %v, want %v", test.input, level, test.expected)
+		}
+	}
+
+	for _, input := range []string{"", "verbose", "warnn"} {
+		if _, err := ParseLevelStrict(input); !errors.Is(err, ErrUnknownLevel) {
+			t.Errorf("ParseLevelStrict(%q) error = %v, want ErrUnknownLevel", input, err)
+		}
+	}
+}
+
+func TestParseLevelStrictCustomLevel(t *testing.T) {
+	audit, err := RegisterLevel("Audit", 25)
+	if err != nil {
+		t.Fatalf("RegisterLevel returned error: %v", err)
+	}
+	defer unregisterLevel(audit)
+
+	level, err := ParseLevelStrict("AUDIT")
+	if err != nil {
+		t.Fatalf("ParseLevelStrict returned error: %v", err)
+	}
+	if level != audit {
+		t.Errorf("Expected custom level, got %v", level)
+	}
+
+	if _, err := RegisterLevel("warning", 26); err == nil {
+		t.Error("Expected an error when registering a name used as an alias")
+	}
+}
+
+func TestLevelTextMarshaling(t *testing.T) {
+	type config struct {
+		Level Level `json:"level"`
+	}
+
+	data, err := json.Marshal(config{Level: WarnLevel})
+	if err != nil {
+		t.Fatalf("Marshal returned error: %v", err)
+	}
+	if string(data) != `{"level":"warn"}` {
+		t.Errorf("Unexpected JSON: %s", data)
+	}
+
+	var cfg config
+	if err := json.Unmarshal([]byte(`{"level":"Warning"}`), &cfg); err != nil {
+		t.Fatalf("Unmarshal returned error: %v", err)
+	}
+	if cfg.Level != WarnLevel {
+		t.Errorf("Expected WarnLevel, got %v", cfg.Level)
+	}
+
+	if err := json.Unmarshal([]byte(`{"level":"loud"}`), &cfg); !errors.Is(err, ErrUnknownLevel) {
+		t.Errorf("Expected ErrUnknownLevel for an unknown level, got %v", err)
+	}
+
+	if _, err := Level(99).MarshalText(); !errors.Is(err, ErrUnknownLevel) {
+		t.Errorf("Expected ErrUnknownLevel for an unregistered level, got %v", err)
+	}
+}
+
+func TestLevelFlag(t *testing.T) {
+	level := InfoLevel
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(&level, "level", "log level")
+
+	if err := fs.Parse([]string{"-level", "ERR"}); err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if level != ErrorLevel {
+		t.Errorf("Expected ErrorLevel, got %v", level)
+	}
+
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse([]string{"-level", "nope"}); err == nil {
+		t.Error("Expected an error for an unknown level")
+	}
+}
@@ -1,27 +1,90 @@
 package pdalog
 
 import (
+	"context"
+	"errors"
 	"io"
 	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
-// Logger represents the core logger structure
+// Logger represents the core logger structure. Loggers derived with With
+// are children of the logger they were created from: they share its outputs
+// and hooks, and follow its level until they set their own.
 type Logger struct {
-	writer        io.Writer
-	level         Level
-	mu            sync.Mutex
-	timeFormat    string
-	contextFields map[string]interface{}
+	core   *loggerCore
+	parent *Logger
+	// level is only used when levelSet is true, otherwise the parent's level applies
+	level    atomic.Int32
+	levelSet atomic.Bool
+	// hooks are the hooks added to this logger; ancestors' hooks fire as well
 	hooks         []Hook
+	contextFields map[string]interface{}
+	// sampler is only used when samplerSet is true, otherwise the parent's sampler applies
+	sampler    Sampler
+	samplerSet bool
+	// context holds contextFields pre-encoded with each of the core's
+	// encoders, so events copy bytes instead of re-encoding
+	context [][]byte
+	// name is set by Named, nameField holds the component field encoded with
+	// each of the core's encoders
+	name      string
+	nameField [][]byte
+	// componentCache is the level of the name, see componentLevel
+	componentCache atomic.Pointer[componentLevelCache]
+}
+
+// loggerCore is the state shared by a root logger and all of its children
+type loggerCore struct {
+	outputs    []*output
+	timeFormat string
+	// encoders are the distinct encoders of the outputs
+	encoders []Encoder
+	// contextExtractors are run by Event.Ctx
+	contextExtractors []ContextExtractor
+	exitFunc          func(code int)
+	errorHandler      ErrorHandler
+	stats             loggerStats
+	sampling          samplingStats
+	components        componentState
+	// hooksMu guards the hooks of every logger sharing this core
+	hooksMu sync.RWMutex
 }
 
+// timeNow is the clock used to timestamp events, replaceable in tests
+var timeNow = time.Now
+
 // Options for configuring a new logger
 type Options struct {
 	Writer     io.Writer
 	Level      Level
 	TimeFormat string
+	// Encoder renders the log lines, JSONEncoder is used when nil
+	Encoder Encoder
+	// Outputs replace Writer and Encoder with several destinations, each
+	// with its own minimum level and encoder. Level still applies to all of them.
+	Outputs []Output
+	// ContextExtractors add request-scoped fields from a context.Context
+	// when Event.Ctx is called
+	ContextExtractors []ContextExtractor
+	// ExitFunc is called by Fatal events after the logger has been synced,
+	// os.Exit is used when nil
+	ExitFunc func(code int)
+	// ErrorHandler receives write, hook and sync errors; they are printed to
+	// os.Stderr when nil
+	ErrorHandler ErrorHandler
+	// Sampler drops a share of the events, see Logger.WithSampler to sample
+	// only some child loggers
+	Sampler Sampler
+	// SampleReportInterval is how often an entry counting the sampled-out
+	// events of each level is written, every minute when zero; a negative
+	// interval disables the report
+	SampleReportInterval time.Duration
+	// ComponentLevels sets the levels of loggers created with Named, see
+	// Logger.SetComponentLevels; invalid patterns are reported to ErrorHandler
+	ComponentLevels map[string]Level
 }
 
 // DefaultOptions returns the default logger options
@@ -30,24 +93,50 @@ func DefaultOptions() Options {
 		Writer:     os.Stdout,
 		Level:      InfoLevel,
 		TimeFormat: time.RFC3339,
+		Encoder:    JSONEncoder{},
 	}
 }
 
 // New creates a new logger with the given options
 func New(opts Options) *Logger {
-	if opts.Writer == nil {
-		opts.Writer = os.Stdout
-	}
 	if opts.TimeFormat == "" {
 		opts.TimeFormat = time.RFC3339
 	}
+	if opts.ExitFunc == nil {
+		opts.ExitFunc = os.Exit
+	}
+	if opts.ErrorHandler == nil {
+		opts.ErrorHandler = defaultErrorHandler
+	}
+	if opts.SampleReportInterval == 0 {
+		opts.SampleReportInterval = time.Minute
+	}
 
-	return &Logger{
-		writer:        opts.Writer,
-		level:         opts.Level,
-		timeFormat:    opts.TimeFormat,
+	outputs, encoders := newOutputs(opts)
+	l := &Logger{
+		core: &loggerCore{
+			outputs:    outputs,
+			timeFormat: opts.TimeFormat,
+			encoders:   encoders,
+			// Copy so later changes to opts do not affect the logger
+			contextExtractors: append([]ContextExtractor(nil), opts.ContextExtractors...),
+			exitFunc:          opts.ExitFunc,
+			errorHandler:      opts.ErrorHandler,
+		},
 		contextFields: make(map[string]interface{}),
+		context:       make([][]byte, len(encoders)),
+		sampler:       opts.Sampler,
+		samplerSet:    opts.Sampler != nil,
+	}
+	l.core.sampling.interval = opts.SampleReportInterval
+	l.core.sampling.nextReport.Store(timeNow().Add(opts.SampleReportInterval).UnixNano())
+	l.SetLevel(opts.Level)
+	if len(opts.ComponentLevels) > 0 {
+		if err := l.SetComponentLevels(opts.ComponentLevels); err != nil {
+			opts.ErrorHandler(err)
+		}
 	}
+	return l
 }
 
 // NewConsoleLogger creates a new logger with console output
@@ -56,38 +145,46 @@ func NewConsoleLogger() *Logger {
 	return New(opts)
 }
 
-// SetLevel sets the logger's minimum level
+// SetLevel sets the logger's minimum level. On a root logger the change
+// propagates to all children that have not set their own level; on a child it
+// overrides the inherited level for the child and its descendants.
 func (l *Logger) SetLevel(level Level) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	l.level = level
+	l.level.Store(int32(level))
+	l.levelSet.Store(true)
 }
 
-// GetLevel returns the current logger level
-func (l *Logger) GetLevel() Level {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	return l.level
+// InheritLevel drops a level set on a child logger, so it follows its parent
+// again. It has no effect on a root logger.
+func (l *Logger) InheritLevel() {
+	if l.parent != nil {
+		l.levelSet.Store(false)
+	}
 }
 
-// With returns a new logger with the given field added to its context
-func (l *Logger) With(key string, value interface{}) *Logger {
-	newLogger := &Logger{
-		writer:        l.writer,
-		level:         l.level,
-		timeFormat:    l.timeFormat,
-		contextFields: make(map[string]interface{}),
+// GetLevel returns the current logger level. For named loggers it is the
+// component level, see SetComponentLevels.
+func (l *Logger) GetLevel() Level {
+	lg := l
+	for ; lg.parent != nil; lg = lg.parent {
+		if lg.levelSet.Load() {
+			return Level(lg.level.Load())
+		}
 	}
-
-	// Copy existing context fields
-	for k, v := range l.contextFields {
-		newLogger.contextFields[k] = v
+	if level, ok := l.componentLevel(); ok {
+		return level
 	}
+	return Level(lg.level.Load())
+}
 
-	// Add new field
-	newLogger.contextFields[key] = value
+// With returns a new child logger with the given field added to its context.
+// Use WithFields to add several fields at once.
+func (l *Logger) With(key string, value interface{}) *Logger {
+	return l.WithFields().Any(key, value).Logger()
+}
 
-	return newLogger
+// Trace returns a trace level event logger
+func (l *Logger) Trace() *Event {
+	return l.newEvent(TraceLevel)
 }
 
 // Debug returns a debug level event logger
@@ -115,45 +212,180 @@ func (l *Logger) Fatal() *Event {
 	return l.newEvent(FatalLevel)
 }
 
+// Panic returns a panic level event logger, Msg panics after logging
+func (l *Logger) Panic() *Event {
+	return l.newEvent(PanicLevel)
+}
+
+// WithLevel returns an event logger for the given level, including custom
+// levels registered with RegisterLevel
+func (l *Logger) WithLevel(level Level) *Event {
+	return l.newEvent(level)
+}
+
 // newEvent creates a new Event with the given level
 func (l *Logger) newEvent(level Level) *Event {
-	if level < l.level {
+	if level < l.GetLevel() {
+		return nil
+	}
+	l.reportSampled()
+	if !l.sampled(level) {
 		return nil
 	}
 
-	e := &Event{
-		logger: l,
-		level:  level,
-		fields: make(map[string]interface{}),
-		time:   time.Now(),
+	e := newPooledEvent(l, level)
+
+	// Context fields precede the event's own fields, led by the component
+	// of named loggers
+	for i := range e.bufs {
+		if l.nameField == nil {
+			e.bufs[i] = append(e.bufs[i], l.context[i]...)
+			continue
+		}
+		e.bufs[i] = append(e.bufs[i], l.nameField[i]...)
+		e.bufs[i] = e.encs[i].AppendFields(e.bufs[i], l.context[i])
 	}
 
-	// Add context fields
-	for k, v := range l.contextFields {
-		e.fields[k] = v
+	// Hooks receive the entry as a map, so only build one when a hook will fire
+	if l.hasHookFor(level) {
+		e.fields = make(map[string]interface{}, len(l.contextFields)+8)
+		for k, v := range l.contextFields {
+			e.fields[k] = v
+		}
 	}
 
 	return e
 }
 
-// AddHook adds a hook to the logger
+// hasHookFor reports whether any hook of the logger or its ancestors fires for the level
+func (l *Logger) hasHookFor(level Level) bool {
+	l.core.hooksMu.RLock()
+	defer l.core.hooksMu.RUnlock()
+	for lg := l; lg != nil; lg = lg.parent {
+		for _, hook := range lg.hooks {
+			if hookFiresFor(hook, level) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// fireHooks fires the hooks registered for the level, ancestors' hooks first.
+// The hooks are copied under hooksMu and fired without it, so a slow hook
+// does not block other loggers and hooks may add or remove hooks.
+func (l *Logger) fireHooks(ctx context.Context, level Level, entry map[string]interface{}) {
+	l.core.hooksMu.RLock()
+	hooks := l.allHooks()
+	l.core.hooksMu.RUnlock()
+
+	for _, hook := range hooks {
+		if !hookFiresFor(hook, level) {
+			continue
+		}
+		var err error
+		if ch, ok := hook.(ContextHook); ok {
+			err = ch.FireContext(ctx, entry)
+		} else {
+			err = hook.Fire(entry)
+		}
+		if err != nil {
+			l.core.stats.hookFailures.Add(1)
+			l.core.errorHandler(&HookError{Hook: hook, Entry: entry, Err: err})
+		}
+	}
+}
+
+// AddHook adds a hook to the logger. Hooks added to a logger also fire for
+// events of its children, including children created before the hook was added.
 func (l *Logger) AddHook(hook Hook) *Logger {
-	l.mu.Lock()
-	defer l.mu.Unlock()
+	l.core.hooksMu.Lock()
+	defer l.core.hooksMu.Unlock()
 	l.hooks = append(l.hooks, hook)
 	return l
 }
 
-// RemoveHook removes a hook from the logger
+// RemoveHook removes a hook from the logger. Hooks inherited from a parent
+// must be removed from the parent.
 func (l *Logger) RemoveHook(hook Hook) *Logger {
-	l.mu.Lock()
-	defer l.mu.Unlock()
+	l.core.hooksMu.Lock()
+	defer l.core.hooksMu.Unlock()
 
 	for i, h := range l.hooks {
 		if h == hook {
-			l.hooks = append(l.hooks[:i], l.hooks[i+1:]...)
+			l.hooks = append(l.hooks[:i:i], l.hooks[i+1:]...)
 			break
 		}
 	}
 	return l
 }
+
+// Sync writes the pending sampling report and flushes the writer and the
+// hooks of the logger and its ancestors that buffer entries, see Flusher.
+// Hooks added only to children of this logger are not flushed.
+func (l *Logger) Sync() error {
+	return l.sync(context.Background())
+}
+
+// Close syncs the logger and then closes the outputs and the hooks of the
+// logger and its ancestors that implement io.Closer. os.Stdout and os.Stderr
+// are never closed. The logger must not be used after Close.
+func (l *Logger) Close() error {
+	l.stopSampleReports()
+	err := l.Sync()
+
+	l.core.hooksMu.RLock()
+	hooks := l.allHooks()
+	l.core.hooksMu.RUnlock()
+	for _, hook := range hooks {
+		if c, ok := hook.(io.Closer); ok {
+			err = errors.Join(err, c.Close())
+		}
+	}
+
+	for i, out := range l.core.outputs {
+		if l.core.sharesWriter(i) {
+			continue
+		}
+		out.mu.Lock()
+		if c, ok := out.writer.(io.Closer); ok && !isStdStream(out.writer) {
+			err = errors.Join(err, c.Close())
+		}
+		out.mu.Unlock()
+	}
+	return err
+}
+
+// sync writes the pending sampling report and flushes the outputs and
+// hooks, giving up on hooks when ctx is done
+func (l *Logger) sync(ctx context.Context) error {
+	l.writeSampledReport()
+
+	l.core.hooksMu.RLock()
+	hooks := l.allHooks()
+	l.core.hooksMu.RUnlock()
+
+	var err error
+	for _, hook := range hooks {
+		err = errors.Join(err, flush(ctx, hook))
+	}
+
+	for i, out := range l.core.outputs {
+		if l.core.sharesWriter(i) {
+			continue
+		}
+		out.mu.Lock()
+		err = errors.Join(err, flush(ctx, out.writer))
+		out.mu.Unlock()
+	}
+	return err
+}
+
+// allHooks returns the hooks of the logger and its ancestors, l.core.hooksMu must be held
+func (l *Logger) allHooks() []Hook {
+	var hooks []Hook
+	if l.parent != nil {
+		hooks = l.parent.allHooks()
+	}
+	return append(hooks, l.hooks...)
+}
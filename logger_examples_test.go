@@ -2,10 +2,13 @@ package pdalog_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/pdat-cz/go-pda-log"
+	"log/slog"
+	"os"
 	"strings"
 	"time"
 )
@@ -381,3 +384,141 @@ func ExampleParseLevel() {
 	// Fatal level: fatal
 	// Unknown level (defaults to info): info
 }
+
+// ExampleConsoleWriter demonstrates rendering log lines for humans
+func ExampleConsoleWriter() {
+	// Colors are disabled so the output is plain text
+	writer := &pdalog.ConsoleWriter{
+		Out:           os.Stdout,
+		NoColor:       true,
+		FieldsExclude: []string{"time"},
+	}
+
+	log := pdalog.New(pdalog.Options{
+		Writer: writer,
+		Level:  pdalog.InfoLevel,
+	})
+
+	log.Info().
+		Str("service", "api").
+		Int("port", 8080).
+		Msg("Server listening")
+
+	// Output:
+	// INF Server listening service=api port=8080
+}
+
+// ExampleLogfmtEncoder demonstrates writing log lines in logfmt
+func ExampleLogfmtEncoder() {
+	var buf bytes.Buffer
+	log := pdalog.New(pdalog.Options{
+		Writer:  &buf,
+		Level:   pdalog.InfoLevel,
+		Encoder: pdalog.LogfmtEncoder{},
+	})
+
+	log.Info().
+		Str("path", "/users").
+		Str("agent", "curl 8.0").
+		Msg("Request received")
+
+	// Strip the leading timestamp, which changes on every run
+	line := buf.String()
+	fmt.Print(line[strings.Index(line, "level="):])
+
+	// Output:
+	// level=info message="Request received" path=/users agent="curl 8.0"
+}
+
+// ExampleNewSlogHandler demonstrates routing log/slog records through a logger
+func ExampleNewSlogHandler() {
+	log, buf := setupLogger()
+
+	// Libraries logging via slog now reach the logger's writer and hooks
+	logger := slog.New(pdalog.NewSlogHandler(log))
+	logger.Info("Cache warmed", slog.Int("entries", 1024), slog.Group("cache", slog.String("name", "users")))
+
+	// Parse the JSON to verify fields
+	entry := parseLogEntry(buf)
+
+	// Print the relevant fields
+	fmt.Println("Message:", entry["message"])
+	fmt.Println("Entries:", entry["entries"])
+	fmt.Println("Cache name:", entry["cache.name"])
+
+	// Output:
+	// Message: Cache warmed
+	// Entries: 1024
+	// Cache name: users
+}
+
+// ExampleParseLevelStrict demonstrates parsing level strings with error reporting
+func ExampleParseLevelStrict() {
+	level, err := pdalog.ParseLevelStrict("WARNING")
+	fmt.Println("Level:", level, "Error:", err)
+
+	_, err = pdalog.ParseLevelStrict("verbose")
+	fmt.Println("Error:", err)
+
+	// Output:
+	// Level: warn Error: <nil>
+	// Error: unknown level: "verbose"
+}
+
+// ExampleLogger_WithFields demonstrates building a child logger with several typed fields
+func ExampleLogger_WithFields() {
+	log, buf := setupLogger()
+
+	// The fields are encoded once and reused by every event of requestLogger
+	requestLogger := log.WithFields().
+		Str("request_id", "req-123456").
+		Int("attempt", 2).
+		Logger()
+
+	requestLogger.Info().Msg("Processing request")
+
+	// Parse the JSON to verify fields
+	entry := parseLogEntry(buf)
+
+	// Print the relevant fields
+	fmt.Println("Message:", entry["message"])
+	fmt.Println("Request ID:", entry["request_id"])
+	fmt.Println("Attempt:", entry["attempt"])
+
+	// Output:
+	// Message: Processing request
+	// Request ID: req-123456
+	// Attempt: 2
+}
+
+// ExampleFromContext demonstrates passing a logger through a context.Context
+func ExampleFromContext() {
+	type ctxKey string
+
+	var buf bytes.Buffer
+	log := pdalog.New(pdalog.Options{
+		Writer: &buf,
+		Level:  pdalog.InfoLevel,
+		// Pull the request ID out of every context passed to Event.Ctx
+		ContextExtractors: []pdalog.ContextExtractor{
+			pdalog.ContextValue("request_id", ctxKey("request_id")),
+		},
+	})
+
+	ctx := context.WithValue(context.Background(), ctxKey("request_id"), "req-123456")
+	ctx = pdalog.WithContext(ctx, log)
+
+	// Deeper in the call stack only the context is available
+	pdalog.FromContext(ctx).Info().Ctx(ctx).Msg("Processing request")
+
+	// Parse the JSON to verify fields
+	entry := parseLogEntry(&buf)
+
+	// Print the relevant fields
+	fmt.Println("Message:", entry["message"])
+	fmt.Println("Request ID:", entry["request_id"])
+
+	// Output:
+	// Message: Processing request
+	// Request ID: req-123456
+}
@@ -1,11 +1,16 @@
 package pdalog
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
 	"errors"
+	"os"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestLoggerLevels(t *testing.T) {
@@ -110,6 +115,27 @@ func TestContextFields(t *testing.T) {
 	}
 }
 
+func TestRepeatedFieldKeys(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := New(Options{Writer: buf, Level: InfoLevel})
+	hook := NewMockHook()
+	log.AddHook(hook)
+
+	log.With("a", 1).With("a", 2).Info().Str("a", "3").Msg("repeated")
+
+	// Every occurrence is written, the last one wins when parsed
+	if !strings.Contains(buf.String(), `"a":1,"a":2,"a":"3"}`) {
+		t.Errorf("Expected every occurrence of the key, got %s", buf.String())
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Failed to parse JSON: %v", err)
+	}
+	if entry["a"] != "3" || hook.FiredEntries[0]["a"] != "3" {
+		t.Errorf("Expected the last value to win, got %v and %v", entry["a"], hook.FiredEntries[0]["a"])
+	}
+}
+
 func TestParseLevelString(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -120,6 +146,8 @@ func TestParseLevelString(t *testing.T) {
 		{"warn", WarnLevel},
 		{"error", ErrorLevel},
 		{"fatal", FatalLevel},
+		{"trace", TraceLevel},
+		{"panic", PanicLevel},
 		{"invalid", InfoLevel}, // Default is InfoLevel
 	}
 
@@ -286,3 +314,362 @@ func (m *MockNatsConn) Publish(subject string, data []byte) error {
 	m.PublishedMessages[subject] = data
 	return nil
 }
+
+func TestFieldOrdering(t *testing.T) {
+	fixed := time.Date(2025, 8, 4, 21, 2, 0, 0, time.UTC)
+	timeNow = func() time.Time { return fixed }
+	defer func() { timeNow = time.Now }()
+
+	buf := &bytes.Buffer{}
+	opts := Options{
+		Writer: buf,
+		Level:  DebugLevel,
+	}
+	log := New(opts).With("service", "api").With("attempt", 2)
+
+	log.Warn().
+		Str("zeta", "last alphabetically").
+		Int("alpha", 1).
+		Bool("mid", false).
+		Msg("ordered")
+
+	expected := `{"time":"2025-08-04T21:02:00Z","level":"warn","message":"ordered",` +
+		`"service":"api","attempt":2,"zeta":"last alphabetically","alpha":1,"mid":false}` + "\n"
+	if buf.String() != expected {
+		t.Errorf("Unexpected log line\n got: %s\nwant: %s", buf.String(), expected)
+	}
+
+	// An event without fields still renders a valid object
+	buf.Reset()
+	New(opts).Info().Msg("bare")
+	expected = `{"time":"2025-08-04T21:02:00Z","level":"info","message":"bare"}` + "\n"
+	if buf.String() != expected {
+		t.Errorf("Unexpected log line\n got: %s\nwant: %s", buf.String(), expected)
+	}
+}
+
+func TestLevelOrdering(t *testing.T) {
+	levels := []Level{TraceLevel, DebugLevel, InfoLevel, WarnLevel, ErrorLevel, FatalLevel, PanicLevel}
+	for i := 1; i < len(levels); i++ {
+		if levels[i-1] >= levels[i] {
+			t.Errorf("Expected %v to be below %v", levels[i-1], levels[i])
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	log := New(Options{
+		Writer: buf,
+		Level:  TraceLevel,
+	})
+	log.Trace().Msg("trace message")
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Failed to parse JSON: %v", err)
+	}
+	if entry["level"] != "trace" {
+		t.Errorf("Expected level to be 'trace', got %v", entry["level"])
+	}
+}
+
+func TestPanicLevel(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := New(Options{
+		Writer: buf,
+		Level:  InfoLevel,
+	})
+	hook := NewMockHook(PanicLevel)
+	log.AddHook(hook)
+
+	defer func() {
+		r := recover()
+		if r != "unrecoverable state" {
+			t.Errorf("Expected panic with the message, got %v", r)
+		}
+		if !bytes.Contains(buf.Bytes(), []byte(`"level":"panic"`)) {
+			t.Errorf("Expected the entry to be written before panicking, got %s", buf.String())
+		}
+		if !hook.Fired {
+			t.Error("Hook did not fire before panicking")
+		}
+	}()
+
+	log.Panic().Msg("unrecoverable state")
+}
+
+func TestRegisterLevel(t *testing.T) {
+	notice, err := RegisterLevel("notice", 15)
+	if err != nil {
+		t.Fatalf("RegisterLevel returned error: %v", err)
+	}
+	defer unregisterLevel(notice)
+
+	if notice <= InfoLevel || notice >= WarnLevel {
+		t.Errorf("Expected notice to be between info and warn, got %d", notice)
+	}
+	if notice.String() != "notice" {
+		t.Errorf("Expected String to return 'notice', got %q", notice.String())
+	}
+	if ParseLevel("notice") != notice {
+		t.Errorf("Expected ParseLevel to return the custom level, got %v", ParseLevel("notice"))
+	}
+
+	if _, err := RegisterLevel("notice", 16); err == nil {
+		t.Error("Expected an error when registering a duplicate name")
+	}
+	if _, err := RegisterLevel("other", 15); err == nil {
+		t.Error("Expected an error when registering a duplicate severity")
+	}
+	if _, err := RegisterLevel("", 17); err == nil {
+		t.Error("Expected an error when registering an empty name")
+	}
+
+	found := false
+	for _, level := range AllLevels() {
+		if level == notice {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("Expected AllLevels to include the custom level")
+	}
+
+	// Thresholds and hooks treat the custom level like a built-in one
+	buf := &bytes.Buffer{}
+	log := New(Options{
+		Writer: buf,
+		Level:  notice,
+	})
+	hook := NewMockHook(notice)
+	log.AddHook(hook)
+
+	log.Info().Msg("below threshold")
+	if buf.Len() > 0 {
+		t.Error("Info message was logged when level is notice")
+	}
+
+	log.WithLevel(notice).Msg("custom level message")
+	if !bytes.Contains(buf.Bytes(), []byte(`"level":"notice"`)) {
+		t.Errorf("Expected custom level name in output, got %s", buf.String())
+	}
+	if len(hook.FiredLevels) != 1 || hook.FiredLevels[0] != notice {
+		t.Errorf("Expected hook to fire once for notice, got %v", hook.FiredLevels)
+	}
+}
+
+// unregisterLevel removes a custom level so tests do not leak registrations
+func unregisterLevel(level Level) {
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+	delete(levelValues, strings.ToLower(levelNames[level]))
+	delete(levelNames, level)
+}
+
+func TestChildLoggerInheritsHooks(t *testing.T) {
+	log := New(Options{
+		Writer: &bytes.Buffer{},
+		Level:  DebugLevel,
+	})
+	rootHook := NewMockHook()
+	log.AddHook(rootHook)
+
+	child := log.With("component", "api")
+	childHook := NewMockHook()
+	child.AddHook(childHook)
+
+	// A hook added to the root after the child was created still fires for it
+	lateHook := NewMockHook()
+	log.AddHook(lateHook)
+
+	child.Info().Msg("from child")
+	log.Info().Msg("from root")
+
+	if len(rootHook.FiredEntries) != 2 {
+		t.Errorf("Expected root hook to fire for root and child, got %d entries", len(rootHook.FiredEntries))
+	}
+	if len(lateHook.FiredEntries) != 2 {
+		t.Errorf("Expected late hook to fire for root and child, got %d entries", len(lateHook.FiredEntries))
+	}
+	if len(childHook.FiredEntries) != 1 {
+		t.Fatalf("Expected child hook to fire only for the child, got %d entries", len(childHook.FiredEntries))
+	}
+	if childHook.FiredEntries[0]["component"] != "api" {
+		t.Errorf("Expected child hook entry to carry context, got %v", childHook.FiredEntries[0]["component"])
+	}
+
+	// Removing the hook from the child leaves the root's hooks intact
+	child.RemoveHook(childHook)
+	child.RemoveHook(rootHook)
+	child.Info().Msg("after removal")
+	if len(childHook.FiredEntries) != 1 {
+		t.Errorf("Expected removed child hook not to fire, got %d entries", len(childHook.FiredEntries))
+	}
+	if len(rootHook.FiredEntries) != 3 {
+		t.Errorf("Expected inherited hook to keep firing, got %d entries", len(rootHook.FiredEntries))
+	}
+}
+
+func TestChildLoggerLevel(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := New(Options{
+		Writer: buf,
+		Level:  InfoLevel,
+	})
+	child := log.With("component", "db")
+	grandchild := child.With("table", "users")
+
+	// Level changes on the root propagate to children
+	log.SetLevel(WarnLevel)
+	if child.GetLevel() != WarnLevel || grandchild.GetLevel() != WarnLevel {
+		t.Errorf("Expected children to follow root level, got %v and %v", child.GetLevel(), grandchild.GetLevel())
+	}
+
+	// A child can override the level for itself and its descendants
+	child.SetLevel(DebugLevel)
+	if log.GetLevel() != WarnLevel {
+		t.Errorf("Expected root level to stay warn, got %v", log.GetLevel())
+	}
+	if grandchild.GetLevel() != DebugLevel {
+		t.Errorf("Expected grandchild to follow child override, got %v", grandchild.GetLevel())
+	}
+	grandchild.Debug().Msg("debug from grandchild")
+	if buf.Len() == 0 {
+		t.Error("Debug message was not logged after child override")
+	}
+
+	// Dropping the override follows the root again
+	child.InheritLevel()
+	if child.GetLevel() != WarnLevel {
+		t.Errorf("Expected child to inherit root level again, got %v", child.GetLevel())
+	}
+
+	// Roots always keep their level
+	log.InheritLevel()
+	if log.GetLevel() != WarnLevel {
+		t.Errorf("Expected InheritLevel to be a no-op on the root, got %v", log.GetLevel())
+	}
+}
+
+// overlapWriter records whether Write was ever called concurrently
+type overlapWriter struct {
+	active  int32
+	overlap int32
+}
+
+func (w *overlapWriter) Write(p []byte) (int, error) {
+	if atomic.AddInt32(&w.active, 1) > 1 {
+		atomic.StoreInt32(&w.overlap, 1)
+	}
+	time.Sleep(time.Microsecond)
+	atomic.AddInt32(&w.active, -1)
+	return len(p), nil
+}
+
+func TestChildLoggerSharesWriteLock(t *testing.T) {
+	w := &overlapWriter{}
+	log := New(Options{
+		Writer: w,
+		Level:  DebugLevel,
+	})
+	child := log.With("component", "api")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(l *Logger) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				l.Info().Msg("concurrent")
+			}
+		}([]*Logger{log, child}[i%2])
+	}
+	wg.Wait()
+
+	if atomic.LoadInt32(&w.overlap) != 0 {
+		t.Error("Parent and child wrote to the shared writer concurrently")
+	}
+}
+
+// closeRecorder is a writer recording Sync and Close calls
+type closeRecorder struct {
+	bytes.Buffer
+	synced bool
+	closed bool
+}
+
+func (w *closeRecorder) Sync() error {
+	w.synced = true
+	return nil
+}
+
+func (w *closeRecorder) Close() error {
+	w.closed = true
+	return nil
+}
+
+func TestFatalFlushesBeforeExit(t *testing.T) {
+	out := &bytes.Buffer{}
+	buffered := bufio.NewWriter(out)
+	exitCode := -1
+	log := New(Options{
+		Writer:   buffered,
+		Level:    InfoLevel,
+		ExitFunc: func(code int) { exitCode = code },
+	})
+
+	inner := newBlockingHook()
+	close(inner.release)
+	hook := NewAsyncHook(inner, AsyncOptions{})
+	log.AddHook(hook)
+
+	log.Fatal().Msg("cannot continue")
+
+	if exitCode != 1 {
+		t.Errorf("Expected exit code 1, got %d", exitCode)
+	}
+	if !bytes.Contains(out.Bytes(), []byte("cannot continue")) {
+		t.Error("Expected buffered writer to be flushed before exit")
+	}
+	if len(inner.messages()) != 1 {
+		t.Errorf("Expected async hook to be drained before exit, got %d entries", len(inner.messages()))
+	}
+	_ = hook.Close()
+}
+
+func TestLoggerSyncAndClose(t *testing.T) {
+	w := &closeRecorder{}
+	log := New(Options{
+		Writer: w,
+		Level:  InfoLevel,
+	})
+	hook := NewAsyncHook(NewMockHook(), AsyncOptions{})
+	log.AddHook(hook)
+	child := log.With("component", "api")
+
+	if err := child.Sync(); err != nil {
+		t.Fatalf("Sync returned error: %v", err)
+	}
+	if !w.synced {
+		t.Error("Expected Sync to sync the writer")
+	}
+
+	if err := log.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	if !w.closed {
+		t.Error("Expected Close to close the writer")
+	}
+	if err := hook.Fire(map[string]interface{}{}); !errors.Is(err, ErrHookClosed) {
+		t.Errorf("Expected Close to close hooks, got %v", err)
+	}
+}
+
+func TestLoggerCloseKeepsStdStreamsOpen(t *testing.T) {
+	log := New(Options{Writer: os.Stderr})
+	if err := log.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	if _, err := os.Stderr.Write(nil); err != nil {
+		t.Errorf("Expected os.Stderr to stay open, got %v", err)
+	}
+}
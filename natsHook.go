@@ -1,10 +1,14 @@
 package pdalog
 
 import (
+	"context"
 	"encoding/json"
-	// nats is imported for users who will pass a real nats.Conn to NewNatsHook
-	_ "github.com/nats-io/nats.go"
-	"strings"
+	"errors"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/nats-io/nuid"
 )
 
 // NatsConn is an interface that defines the methods needed from a NATS connection
@@ -12,48 +16,212 @@ type NatsConn interface {
 	Publish(subject string, data []byte) error
 }
 
+// NatsMsgPublisher is implemented by connections that can publish messages
+// with headers, such as *nats.Conn
+type NatsMsgPublisher interface {
+	PublishMsg(msg *nats.Msg) error
+}
+
+// JetStreamPublisher is the subset of jetstream.JetStream used by NatsHook
+// in JetStream mode
+type JetStreamPublisher interface {
+	PublishMsg(ctx context.Context, msg *nats.Msg, opts ...jetstream.PublishOpt) (*jetstream.PubAck, error)
+}
+
+// JetStreamOptions configures the JetStream mode of NatsHook
+type JetStreamOptions struct {
+	// AckTimeout bounds how long each publish attempt waits for the
+	// server's ack, 5s when zero
+	AckTimeout time.Duration
+	// Retry configures the retries of publishes that time out or find no
+	// stream to store the entry, e.g. while the server restarts
+	Retry RetryOptions
+	// MsgID returns the Nats-Msg-Id of an entry, which the stream uses to
+	// drop duplicates of retried publishes; a unique ID is generated when nil
+	MsgID func(entry map[string]interface{}) string
+}
+
 // NatsHook sends log entries to NATS
 type NatsHook struct {
 	conn    NatsConn
-	subject string
+	js      JetStreamPublisher
+	jsOpts  JetStreamOptions
+	subject subjectTemplate
 	levels  []Level
+	// headers are the fields sent as message headers instead of in the payload
+	headers map[string]struct{}
+	// spool keeps entries that failed to publish, see WithSpool
+	spool *natsSpool
 }
 
-// NewNatsHook creates a new NATS hook.
+// NewNatsHook creates a new NATS hook. Without levels it fires for all
+// levels known when it is created, see AllLevels.
+//
+// The subject may contain placeholders such as "logs.{level}.{component}",
+// which are replaced with the entry's field values of any type. Values are
+// sanitized so that '.', '*', '>' and whitespace cannot split the subject or
+// turn it into a wildcard. A default for missing or empty fields can be given
+// as "{component|unknown}"; without one "unknown" is used.
 func NewNatsHook(conn NatsConn, subject string, levels ...Level) *NatsHook {
 	if len(levels) == 0 {
-		levels = []Level{DebugLevel, InfoLevel, WarnLevel, ErrorLevel, FatalLevel}
+		levels = AllLevels()
 	}
 
 	return &NatsHook{
 		conn:    conn,
-		subject: subject,
+		subject: compileSubject(subject),
 		levels:  levels,
 	}
 }
 
+// WithHeaders sends the given fields as NATS message headers instead of in
+// the JSON payload, e.g. to let consumers filter without decoding. Headers
+// need a connection implementing NatsMsgPublisher, such as *nats.Conn, or
+// JetStream mode; otherwise the fields stay in the payload.
+func (h *NatsHook) WithHeaders(keys ...string) *NatsHook {
+	if h.headers == nil {
+		h.headers = make(map[string]struct{}, len(keys))
+	}
+	for _, key := range keys {
+		h.headers[key] = struct{}{}
+	}
+	return h
+}
+
+// NewJetStreamHook creates a NATS hook publishing to a JetStream stream
+// covering the subject. Unlike core NATS publishing, every entry is
+// acknowledged by the server, retried with the same Nats-Msg-Id when the ack
+// does not arrive, and reported through the logger's ErrorHandler when the
+// retries are exhausted. Wrap the hook in an AsyncHook to keep the acks off
+// the logging path.
+func NewJetStreamHook(js JetStreamPublisher, subject string, opts JetStreamOptions, levels ...Level) *NatsHook {
+	if opts.AckTimeout <= 0 {
+		opts.AckTimeout = 5 * time.Second
+	}
+	opts.Retry = opts.Retry.withDefaults()
+
+	h := NewNatsHook(nil, subject, levels...)
+	h.js = js
+	h.jsOpts = opts
+	return h
+}
+
 // Fire sends the log entry to NATS
 func (h *NatsHook) Fire(entry map[string]interface{}) error {
+	msg := nats.NewMsg(h.subject.render(entry))
 
-	subject := h.subject
-
-	// Simple variable substitution
-	for key, value := range entry {
-		if strValue, ok := value.(string); ok {
-			placeholder := "{" + key + "}"
-			subject = strings.Replace(subject, placeholder, strValue, -1)
+	payload := entry
+	if len(h.headers) > 0 && h.supportsHeaders() {
+		payload = make(map[string]interface{}, len(entry))
+		for key, value := range entry {
+			if _, ok := h.headers[key]; ok {
+				msg.Header.Set(key, formatFieldValue(value))
+				continue
+			}
+			payload[key] = value
 		}
 	}
 
-	data, err := json.Marshal(entry)
+	data, err := json.Marshal(payload)
 	if err != nil {
 		return err
 	}
+	msg.Data = data
+
+	if h.js != nil {
+		// The ID is set once, so replays from the spool are deduplicated as well
+		id := ""
+		if h.jsOpts.MsgID != nil {
+			id = h.jsOpts.MsgID(entry)
+		}
+		if id == "" {
+			id = nuid.Next()
+		}
+		msg.Header.Set(jetstream.MsgIDHeader, id)
+	}
+
+	if h.spool != nil {
+		return h.spool.send(msg)
+	}
+	return h.publish(msg)
+}
+
+// Flush replays the spooled entries and flushes the connection, so entries
+// buffered by the NATS client have reached the server, e.g. before a Fatal
+// event exits. Connections with FlushWithContext, such as *nats.Conn, use it
+// when ctx has a deadline and Flush otherwise.
+func (h *NatsHook) Flush(ctx context.Context) error {
+	if h.spool != nil {
+		if err := h.spool.drain(ctx); err != nil {
+			return err
+		}
+	}
 
-	return h.conn.Publish(subject, data)
+	if c, ok := h.conn.(interface {
+		FlushWithContext(ctx context.Context) error
+	}); ok {
+		if _, hasDeadline := ctx.Deadline(); hasDeadline {
+			return c.FlushWithContext(ctx)
+		}
+	}
+	if c, ok := h.conn.(interface{ Flush() error }); ok {
+		return c.Flush()
+	}
+	return nil
+}
+
+// publish sends the message using JetStream, PublishMsg or Publish
+func (h *NatsHook) publish(msg *nats.Msg) error {
+	if h.js != nil {
+		return h.publishJetStream(msg)
+	}
+	if len(msg.Header) > 0 {
+		return h.conn.(NatsMsgPublisher).PublishMsg(msg)
+	}
+	return h.conn.Publish(msg.Subject, msg.Data)
 }
 
 // Levels returns the log levels this hook should be triggered for
 func (h *NatsHook) Levels() []Level {
 	return h.levels
 }
+
+// publishJetStream publishes the entry and waits for its ack, retrying
+// timeouts with the same message ID so the stream stores it only once. The
+// event's context is not used, so entries about canceled requests are delivered.
+func (h *NatsHook) publishJetStream(msg *nats.Msg) error {
+	ctx := context.Background()
+	backoff := h.jsOpts.Retry.InitialBackoff
+	for attempt := 0; ; attempt++ {
+		pubCtx, cancel := context.WithTimeout(ctx, h.jsOpts.AckTimeout)
+		_, err := h.js.PublishMsg(pubCtx, msg)
+		cancel()
+		if err == nil || !retryablePublishError(err) || attempt == h.jsOpts.Retry.MaxRetries {
+			return err
+		}
+
+		var ok bool
+		if backoff, ok = h.jsOpts.Retry.wait(ctx, backoff); !ok {
+			return err
+		}
+	}
+}
+
+// supportsHeaders reports whether messages with headers can be published
+func (h *NatsHook) supportsHeaders() bool {
+	if h.js != nil {
+		return true
+	}
+	_, ok := h.conn.(NatsMsgPublisher)
+	return ok
+}
+
+// retryablePublishError reports whether a JetStream publish may succeed when
+// retried: the ack timed out or no stream responded, as happens while the
+// server restarts
+func retryablePublishError(err error) bool {
+	return errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, nats.ErrTimeout) ||
+		errors.Is(err, nats.ErrNoResponders) ||
+		errors.Is(err, jetstream.ErrNoStreamResponse)
+}
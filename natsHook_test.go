@@ -0,0 +1,292 @@
+package pdalog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// startJetStream runs an embedded NATS server with JetStream and a LOGS stream
+func startJetStream(t *testing.T) (*server.Server, jetstream.JetStream, jetstream.Stream) {
+	t.Helper()
+	srv, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	if err != nil {
+		t.Fatalf("NewServer returned error: %v", err)
+	}
+	srv.Start()
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatal("NATS server did not start")
+	}
+	t.Cleanup(srv.Shutdown)
+
+	nc, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatalf("Connect returned error: %v", err)
+	}
+	t.Cleanup(nc.Close)
+
+	js, err := jetstream.New(nc)
+	if err != nil {
+		t.Fatalf("jetstream.New returned error: %v", err)
+	}
+	stream, err := js.CreateStream(context.Background(), jetstream.StreamConfig{
+		Name:       "LOGS",
+		Subjects:   []string{"logs.>"},
+		Duplicates: time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("CreateStream returned error: %v", err)
+	}
+	return srv, js, stream
+}
+
+func TestJetStreamHook(t *testing.T) {
+	_, js, stream := startJetStream(t)
+
+	hook := NewJetStreamHook(js, "logs.{level}", JetStreamOptions{})
+	log := New(Options{Writer: &bytes.Buffer{}})
+	log.AddHook(hook)
+	log.Info().Str("component", "api").Msg("stored")
+	log.Error().Msg("stored too")
+
+	ctx := context.Background()
+	info, err := stream.Info(ctx)
+	if err != nil {
+		t.Fatalf("Info returned error: %v", err)
+	}
+	if info.State.Msgs != 2 {
+		t.Fatalf("Expected 2 stored messages, got %d", info.State.Msgs)
+	}
+
+	msg, err := stream.GetMsg(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetMsg returned error: %v", err)
+	}
+	if msg.Subject != "logs.info" {
+		t.Errorf("Expected subject logs.info, got %s", msg.Subject)
+	}
+	if msg.Header.Get(jetstream.MsgIDHeader) == "" {
+		t.Error("Expected a Nats-Msg-Id header")
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal(msg.Data, &entry); err != nil {
+		t.Fatalf("Unmarshal returned error: %v", err)
+	}
+	if entry["message"] != "stored" || entry["component"] != "api" {
+		t.Errorf("Unexpected entry %v", entry)
+	}
+}
+
+func TestJetStreamHookDeduplication(t *testing.T) {
+	_, js, stream := startJetStream(t)
+
+	hook := NewJetStreamHook(js, "logs.audit", JetStreamOptions{
+		MsgID: func(entry map[string]interface{}) string {
+			id, _ := entry["event_id"].(string)
+			return id
+		},
+	})
+	entry := map[string]interface{}{"level": "info", "message": "login", "event_id": "evt-1"}
+	for i := 0; i < 2; i++ {
+		if err := hook.Fire(entry); err != nil {
+			t.Fatalf("Fire returned error: %v", err)
+		}
+	}
+
+	info, err := stream.Info(context.Background())
+	if err != nil {
+		t.Fatalf("Info returned error: %v", err)
+	}
+	if info.State.Msgs != 1 {
+		t.Errorf("Expected the duplicate to be dropped, got %d messages", info.State.Msgs)
+	}
+}
+
+func TestJetStreamHookNoStream(t *testing.T) {
+	_, js, _ := startJetStream(t)
+
+	recorder := &errorRecorder{}
+	hook := NewJetStreamHook(js, "metrics.cpu", JetStreamOptions{
+		Retry: RetryOptions{MaxRetries: 2, InitialBackoff: time.Millisecond},
+	})
+	log := New(Options{Writer: &bytes.Buffer{}, ErrorHandler: recorder.handle})
+	log.AddHook(hook)
+	log.Info().Msg("no stream covers this subject")
+
+	if len(recorder.errors) != 1 {
+		t.Fatalf("Expected 1 error, got %d", len(recorder.errors))
+	}
+	var hookErr *HookError
+	if !errors.As(recorder.errors[0], &hookErr) || !errors.Is(recorder.errors[0], jetstream.ErrNoStreamResponse) {
+		t.Errorf("Expected a HookError wrapping ErrNoStreamResponse, got %v", recorder.errors[0])
+	}
+}
+
+// flakyPublisher times out its first failures publishes
+type flakyPublisher struct {
+	mu       sync.Mutex
+	failures int
+	ids      []string
+	err      error
+}
+
+func (p *flakyPublisher) PublishMsg(_ context.Context, msg *nats.Msg, _ ...jetstream.PublishOpt) (*jetstream.PubAck, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.ids = append(p.ids, msg.Header.Get(jetstream.MsgIDHeader))
+	if len(p.ids) <= p.failures {
+		return nil, p.err
+	}
+	return &jetstream.PubAck{Stream: "LOGS", Sequence: 1}, nil
+}
+
+func TestJetStreamHookRetries(t *testing.T) {
+	publisher := &flakyPublisher{failures: 2, err: context.DeadlineExceeded}
+	hook := NewJetStreamHook(publisher, "logs", JetStreamOptions{
+		Retry: RetryOptions{MaxRetries: 3, InitialBackoff: time.Millisecond},
+	})
+
+	if err := hook.Fire(map[string]interface{}{"message": "retried"}); err != nil {
+		t.Fatalf("Fire returned error: %v", err)
+	}
+	if len(publisher.ids) != 3 {
+		t.Fatalf("Expected 3 attempts, got %d", len(publisher.ids))
+	}
+	if publisher.ids[0] == "" || publisher.ids[0] != publisher.ids[1] || publisher.ids[1] != publisher.ids[2] {
+		t.Errorf("Expected retries to reuse the message ID, got %v", publisher.ids)
+	}
+
+	// Errors other than timeouts are not retried
+	permanent := &flakyPublisher{failures: 5, err: errors.New("maximum payload exceeded")}
+	hook = NewJetStreamHook(permanent, "logs", JetStreamOptions{
+		Retry: RetryOptions{InitialBackoff: time.Millisecond},
+	})
+	if err := hook.Fire(map[string]interface{}{"message": "too big"}); err == nil {
+		t.Error("Expected an error")
+	}
+	if len(permanent.ids) != 1 {
+		t.Errorf("Expected 1 attempt, got %d", len(permanent.ids))
+	}
+}
+
+// msgPublisherConn records messages published with headers
+type msgPublisherConn struct {
+	MockNatsConn
+	msgs []*nats.Msg
+}
+
+func (c *msgPublisherConn) PublishMsg(msg *nats.Msg) error {
+	c.msgs = append(c.msgs, msg)
+	return nil
+}
+
+func TestNatsHookHeaders(t *testing.T) {
+	conn := &msgPublisherConn{MockNatsConn: MockNatsConn{PublishedMessages: make(map[string][]byte)}}
+	hook := NewNatsHook(conn, "logs.{level}").WithHeaders("request_id", "status")
+
+	err := hook.Fire(map[string]interface{}{"level": "info", "message": "done", "request_id": "abc", "status": 200})
+	if err != nil {
+		t.Fatalf("Fire returned error: %v", err)
+	}
+	if len(conn.msgs) != 1 {
+		t.Fatalf("Expected 1 message published with headers, got %d", len(conn.msgs))
+	}
+	msg := conn.msgs[0]
+	if msg.Subject != "logs.info" || msg.Header.Get("request_id") != "abc" || msg.Header.Get("status") != "200" {
+		t.Errorf("Unexpected message %s %v", msg.Subject, msg.Header)
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal(msg.Data, &payload); err != nil {
+		t.Fatalf("Unmarshal returned error: %v", err)
+	}
+	if _, ok := payload["request_id"]; ok {
+		t.Error("Expected header fields to be removed from the payload")
+	}
+	if payload["message"] != "done" {
+		t.Errorf("Unexpected payload %v", payload)
+	}
+
+	// Without PublishMsg the fields stay in the payload
+	plain := &MockNatsConn{PublishedMessages: make(map[string][]byte)}
+	hook = NewNatsHook(plain, "logs").WithHeaders("request_id")
+	if err := hook.Fire(map[string]interface{}{"request_id": "abc"}); err != nil {
+		t.Fatalf("Fire returned error: %v", err)
+	}
+	if !bytes.Contains(plain.PublishedMessages["logs"], []byte(`"request_id":"abc"`)) {
+		t.Errorf("Expected the field in the payload, got %s", plain.PublishedMessages["logs"])
+	}
+}
+
+func TestJetStreamHookHeaders(t *testing.T) {
+	_, js, stream := startJetStream(t)
+
+	hook := NewJetStreamHook(js, "logs.{component|core}", JetStreamOptions{}).WithHeaders("component")
+	if err := hook.Fire(map[string]interface{}{"level": "warn", "component": "db.pool"}); err != nil {
+		t.Fatalf("Fire returned error: %v", err)
+	}
+
+	msg, err := stream.GetMsg(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("GetMsg returned error: %v", err)
+	}
+	if msg.Subject != "logs.db_pool" || msg.Header.Get("component") != "db.pool" {
+		t.Errorf("Unexpected message %s %v", msg.Subject, msg.Header)
+	}
+	if msg.Header.Get(jetstream.MsgIDHeader) == "" {
+		t.Error("Expected a Nats-Msg-Id header next to the promoted fields")
+	}
+}
+
+func TestNatsHookFlushesConnectionBeforeFatalExit(t *testing.T) {
+	srv, _, _ := startJetStream(t)
+	nc, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatalf("Connect returned error: %v", err)
+	}
+	defer nc.Close()
+	cid, err := nc.GetClientID()
+	if err != nil {
+		t.Fatalf("GetClientID returned error: %v", err)
+	}
+
+	// received returns the number of messages the server got from nc
+	received := func() int64 {
+		connz, err := srv.Connz(&server.ConnzOptions{CID: cid})
+		if err != nil || len(connz.Conns) != 1 {
+			t.Fatalf("Connz returned %v, %v", connz, err)
+		}
+		return connz.Conns[0].InMsgs
+	}
+
+	var atExit int64
+	log := New(Options{
+		Writer:   &bytes.Buffer{},
+		Level:    InfoLevel,
+		ExitFunc: func(int) { atExit = received() },
+	})
+	log.AddHook(NewNatsHook(nc, "logs.core"))
+
+	for i := 0; i < 999; i++ {
+		log.Info().Int("i", i).Msg("entry")
+	}
+	log.Fatal().Msg("shutting down")
+
+	if atExit != 1000 {
+		t.Errorf("Expected the server to have received 1000 entries before exiting, got %d", atExit)
+	}
+}
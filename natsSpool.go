@@ -0,0 +1,293 @@
+package pdalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// SpoolOptions configures the spool of a NatsHook
+type SpoolOptions struct {
+	// MaxEntries caps the number of spooled entries, 10000 when zero
+	MaxEntries int
+	// MaxBytes caps the size of the spooled payloads, 8 MiB when zero
+	MaxBytes int
+	// RetryInterval is the wait between replay attempts, 1s when zero
+	RetryInterval time.Duration
+}
+
+// SpoolStats holds the counters of a NatsHook spool
+type SpoolStats struct {
+	// Spooled is the number of entries captured because publishing failed
+	Spooled uint64
+	// Replayed is the number of spooled entries published later
+	Replayed uint64
+	// Dropped is the number of spooled entries discarded to respect the caps
+	Dropped uint64
+	// Failed is the number of spooled entries discarded because replaying
+	// them failed with an error other than a disconnect
+	Failed uint64
+	// Pending is the number of entries currently spooled
+	Pending int
+}
+
+// natsSpool keeps messages that could not be published and replays them in
+// order once publishing succeeds again
+type natsSpool struct {
+	opts    SpoolOptions
+	publish func(msg *nats.Msg) error
+
+	// mu guards queue, bytes, replaying and closed
+	mu        sync.Mutex
+	queue     []*nats.Msg
+	bytes     int
+	replaying bool
+	closed    bool
+	// drainMu serializes replays, so a message is never published twice
+	drainMu sync.Mutex
+	done    chan struct{}
+	replay  sync.WaitGroup
+
+	spooled  atomic.Uint64
+	replayed atomic.Uint64
+	dropped  atomic.Uint64
+	failed   atomic.Uint64
+}
+
+// WithSpool keeps entries that fail to publish, e.g. while the connection is
+// down, in a bounded in-memory spool and replays them in order once
+// publishing succeeds again. While entries are spooled, new entries are
+// appended to the spool to preserve the order. When a cap is reached the
+// oldest entries are dropped. Close the hook, or the logger it was added to,
+// to stop replaying.
+//
+// Only disconnects and retryable JetStream errors spool an entry. Other
+// errors, e.g. a payload over the server's limit, are returned from Fire, and
+// a spooled entry failing with one is dropped on replay and counted in
+// SpoolStats.Failed, so it does not hold up the entries behind it.
+//
+// A *nats.Conn that lost its server buffers publishes while it reconnects
+// without returning an error. Entries are therefore spooled whenever the
+// connection reports it is not connected, so the reconnect buffer is not
+// relied on and can stay at its default size.
+func (h *NatsHook) WithSpool(opts SpoolOptions) *NatsHook {
+	if opts.MaxEntries <= 0 {
+		opts.MaxEntries = 10000
+	}
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = 8 << 20
+	}
+	if opts.RetryInterval <= 0 {
+		opts.RetryInterval = time.Second
+	}
+	h.spool = &natsSpool{
+		opts:    opts,
+		publish: h.publishConnected,
+		done:    make(chan struct{}),
+	}
+	return h
+}
+
+// SpoolStats returns a snapshot of the spool counters, zero without a spool
+func (h *NatsHook) SpoolStats() SpoolStats {
+	if h.spool == nil {
+		return SpoolStats{}
+	}
+	s := h.spool
+	s.mu.Lock()
+	pending := len(s.queue)
+	s.mu.Unlock()
+	return SpoolStats{
+		Spooled:  s.spooled.Load(),
+		Replayed: s.replayed.Load(),
+		Dropped:  s.dropped.Load(),
+		Failed:   s.failed.Load(),
+		Pending:  pending,
+	}
+}
+
+// Close replays the spooled entries once more and stops replaying. Entries
+// that are still spooled are lost and reported in the returned error.
+func (h *NatsHook) Close() error {
+	if h.spool == nil {
+		return nil
+	}
+	return h.spool.close()
+}
+
+// errNotConnected is returned for publishes while the connection is down
+var errNotConnected = errors.New("nats: not connected")
+
+// publishConnected publishes the message if the connection is up
+func (h *NatsHook) publishConnected(msg *nats.Msg) error {
+	if !h.connected() {
+		return errNotConnected
+	}
+	return h.publish(msg)
+}
+
+// spoolableError reports whether a publish failed because the connection is
+// down, or with a JetStream error that may succeed when retried
+func spoolableError(err error) bool {
+	return errors.Is(err, errNotConnected) ||
+		errors.Is(err, nats.ErrConnectionClosed) ||
+		errors.Is(err, nats.ErrConnectionReconnecting) ||
+		retryablePublishError(err)
+}
+
+// connected reports whether the connection, or that of the JetStream
+// context, is up; it reports true for connections that cannot tell
+func (h *NatsHook) connected() bool {
+	var conn interface{} = h.conn
+	if h.js != nil {
+		c, ok := h.js.(interface{ Conn() *nats.Conn })
+		if !ok {
+			return true
+		}
+		conn = c.Conn()
+	}
+	if c, ok := conn.(interface{ IsConnected() bool }); ok {
+		return c.IsConnected()
+	}
+	return true
+}
+
+// send publishes the message, spooling it if the connection is down or
+// earlier messages are still spooled
+func (s *natsSpool) send(msg *nats.Msg) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return s.publish(msg)
+	}
+	if len(s.queue) > 0 {
+		s.push(msg)
+		s.mu.Unlock()
+		return nil
+	}
+	s.mu.Unlock()
+
+	if err := s.publish(msg); err == nil || !spoolableError(err) {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return s.publish(msg)
+	}
+	s.push(msg)
+	return nil
+}
+
+// push appends the message, drops the oldest ones beyond the caps and
+// starts replaying, s.mu must be held
+func (s *natsSpool) push(msg *nats.Msg) {
+	s.queue = append(s.queue, msg)
+	s.bytes += spoolSize(msg)
+	s.spooled.Add(1)
+	for len(s.queue) > 1 && (len(s.queue) > s.opts.MaxEntries || s.bytes > s.opts.MaxBytes) {
+		s.bytes -= spoolSize(s.queue[0])
+		s.queue[0] = nil
+		s.queue = s.queue[1:]
+		s.dropped.Add(1)
+	}
+
+	if !s.replaying {
+		s.replaying = true
+		s.replay.Add(1)
+		go s.replayLoop()
+	}
+}
+
+// replayLoop replays the spool every RetryInterval until it is empty or closed
+func (s *natsSpool) replayLoop() {
+	defer s.replay.Done()
+	ticker := time.NewTicker(s.opts.RetryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-s.done:
+			return
+		}
+		_ = s.drain(context.Background())
+
+		s.mu.Lock()
+		if len(s.queue) == 0 {
+			s.replaying = false
+			s.mu.Unlock()
+			return
+		}
+		s.mu.Unlock()
+	}
+}
+
+// drain publishes spooled messages in order until the spool is empty, the
+// connection is down or ctx is done. Messages failing with other errors are
+// dropped, the first of these errors is returned.
+func (s *natsSpool) drain(ctx context.Context) error {
+	s.drainMu.Lock()
+	defer s.drainMu.Unlock()
+
+	var failed error
+	for {
+		s.mu.Lock()
+		if len(s.queue) == 0 {
+			s.mu.Unlock()
+			return failed
+		}
+		msg := s.queue[0]
+		pending := len(s.queue)
+		s.mu.Unlock()
+
+		if err := ctx.Err(); err != nil {
+			return errors.Join(failed, fmt.Errorf("%d entries still spooled: %w", pending, err))
+		}
+		if err := s.publish(msg); err == nil {
+			s.replayed.Add(1)
+		} else if spoolableError(err) {
+			return errors.Join(failed, fmt.Errorf("%d entries still spooled: %w", pending, err))
+		} else {
+			s.failed.Add(1)
+			if failed == nil {
+				failed = fmt.Errorf("spooled entry dropped: %w", err)
+			}
+		}
+
+		// The message may have been dropped by the caps while it was published
+		s.mu.Lock()
+		if len(s.queue) > 0 && s.queue[0] == msg {
+			s.bytes -= spoolSize(msg)
+			s.queue[0] = nil
+			s.queue = s.queue[1:]
+		}
+		s.mu.Unlock()
+	}
+}
+
+// close drains the spool once more and stops the replay goroutine
+func (s *natsSpool) close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.mu.Unlock()
+
+	err := s.drain(context.Background())
+	close(s.done)
+	s.replay.Wait()
+	return err
+}
+
+// spoolSize is the size a message accounts for against MaxBytes
+func spoolSize(msg *nats.Msg) int {
+	return len(msg.Subject) + len(msg.Data)
+}
@@ -0,0 +1,283 @@
+package pdalog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+)
+
+// outageConn fails publishing while down, rejects payloads containing reject
+// and records published payloads in order
+type outageConn struct {
+	mu        sync.Mutex
+	down      bool
+	reject    string
+	published []string
+}
+
+func (c *outageConn) Publish(_ string, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.down {
+		return nats.ErrConnectionClosed
+	}
+	if c.reject != "" && bytes.Contains(data, []byte(c.reject)) {
+		return nats.ErrMaxPayload
+	}
+	c.published = append(c.published, string(data))
+	return nil
+}
+
+func (c *outageConn) setDown(down bool) {
+	c.mu.Lock()
+	c.down = down
+	c.mu.Unlock()
+}
+
+func (c *outageConn) messages() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.published...)
+}
+
+func TestNatsHookSpoolReplay(t *testing.T) {
+	conn := &outageConn{down: true}
+	hook := NewNatsHook(conn, "logs").WithSpool(SpoolOptions{RetryInterval: 5 * time.Millisecond})
+	defer hook.Close()
+
+	for _, msg := range []string{"one", "two", "three"} {
+		if err := hook.Fire(map[string]interface{}{"message": msg}); err != nil {
+			t.Fatalf("Fire returned error: %v", err)
+		}
+	}
+	if stats := hook.SpoolStats(); stats.Spooled != 3 || stats.Pending != 3 {
+		t.Fatalf("Expected 3 spooled entries, got %+v", stats)
+	}
+
+	// Entries fired after recovery queue behind the spooled ones
+	conn.setDown(false)
+	if err := hook.Fire(map[string]interface{}{"message": "four"}); err != nil {
+		t.Fatalf("Fire returned error: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for hook.SpoolStats().Pending > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Spool was not replayed, stats %+v", hook.SpoolStats())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	published := conn.messages()
+	if len(published) != 4 {
+		t.Fatalf("Expected 4 published entries, got %d", len(published))
+	}
+	for i, msg := range []string{"one", "two", "three", "four"} {
+		if !strings.Contains(published[i], `"message":"`+msg+`"`) {
+			t.Errorf("Expected entry %d to be %q, got %s", i, msg, published[i])
+		}
+	}
+	if stats := hook.SpoolStats(); stats.Replayed != 4 || stats.Dropped != 0 {
+		t.Errorf("Expected 4 replayed entries, got %+v", stats)
+	}
+
+	// Once drained, entries are published directly again
+	if err := hook.Fire(map[string]interface{}{"message": "five"}); err != nil {
+		t.Fatalf("Fire returned error: %v", err)
+	}
+	if len(conn.messages()) != 5 || hook.SpoolStats().Spooled != 4 {
+		t.Errorf("Expected a direct publish, got stats %+v", hook.SpoolStats())
+	}
+}
+
+func TestNatsHookSpoolCaps(t *testing.T) {
+	conn := &outageConn{down: true}
+	hook := NewNatsHook(conn, "logs").WithSpool(SpoolOptions{MaxEntries: 2, RetryInterval: time.Hour})
+	defer hook.Close()
+
+	for _, msg := range []string{"one", "two", "three"} {
+		_ = hook.Fire(map[string]interface{}{"message": msg})
+	}
+	if stats := hook.SpoolStats(); stats.Dropped != 1 || stats.Pending != 2 {
+		t.Fatalf("Expected 1 dropped and 2 pending entries, got %+v", stats)
+	}
+
+	conn.setDown(false)
+	if err := hook.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush returned error: %v", err)
+	}
+	published := conn.messages()
+	if len(published) != 2 || !strings.Contains(published[0], `"two"`) {
+		t.Errorf("Expected the oldest entry to be dropped, got %v", published)
+	}
+
+	// The byte cap applies as well
+	conn.setDown(true)
+	hook = NewNatsHook(conn, "logs").WithSpool(SpoolOptions{MaxBytes: 60, RetryInterval: time.Hour})
+	defer hook.Close()
+	for i := 0; i < 3; i++ {
+		_ = hook.Fire(map[string]interface{}{"message": strings.Repeat("x", 20)})
+	}
+	if stats := hook.SpoolStats(); stats.Pending != 1 || stats.Dropped != 2 {
+		t.Errorf("Expected the byte cap to keep 1 entry, got %+v", stats)
+	}
+}
+
+func TestNatsHookSpoolPoisonEntry(t *testing.T) {
+	conn := &outageConn{reject: "huge"}
+	hook := NewNatsHook(conn, "logs").WithSpool(SpoolOptions{RetryInterval: time.Hour})
+	var reported []error
+	log := New(Options{Writer: &strings.Builder{}, ErrorHandler: func(err error) {
+		reported = append(reported, err)
+	}})
+	log.AddHook(hook)
+
+	// A permanent error is reported instead of spooling the entry
+	log.Info().Msg("huge")
+	for i := 0; i < 5; i++ {
+		log.Info().Int("n", i).Msg("normal")
+	}
+	if len(reported) != 1 || !errors.Is(reported[0], nats.ErrMaxPayload) {
+		t.Errorf("Expected the rejected entry to be reported, got %v", reported)
+	}
+	if stats := hook.SpoolStats(); stats.Spooled != 0 || len(conn.messages()) != 5 {
+		t.Errorf("Expected 5 published entries and none spooled, got %d and %+v", len(conn.messages()), stats)
+	}
+
+	// A spooled entry that fails on replay is dropped, the others are replayed
+	conn.setDown(true)
+	log.Info().Msg("huge")
+	for i := 0; i < 5; i++ {
+		log.Info().Int("n", i).Msg("spooled")
+	}
+	conn.setDown(false)
+	if err := hook.Flush(context.Background()); !errors.Is(err, nats.ErrMaxPayload) {
+		t.Errorf("Expected Flush to report the dropped entry, got %v", err)
+	}
+	if stats := hook.SpoolStats(); stats.Spooled != 6 || stats.Replayed != 5 || stats.Failed != 1 || stats.Pending != 0 {
+		t.Errorf("Expected 5 replayed and 1 failed entry, got %+v", stats)
+	}
+	if published := conn.messages(); len(published) != 10 || !strings.Contains(published[5], `"spooled"`) {
+		t.Errorf("Expected the spooled entries after the direct ones, got %v", published)
+	}
+	if err := log.Close(); err != nil {
+		t.Errorf("Close returned error: %v", err)
+	}
+}
+
+func TestNatsHookSpoolFlushAndClose(t *testing.T) {
+	conn := &outageConn{down: true}
+	hook := NewNatsHook(conn, "logs").WithSpool(SpoolOptions{RetryInterval: time.Hour})
+	log := New(Options{Writer: &strings.Builder{}})
+	log.AddHook(hook)
+
+	log.Info().Msg("spooled")
+	if err := log.Sync(); !errors.Is(err, nats.ErrConnectionClosed) {
+		t.Errorf("Expected Sync to report the spooled entry, got %v", err)
+	}
+	if err := log.Close(); err == nil || !strings.Contains(err.Error(), "1 entries still spooled") {
+		t.Errorf("Expected Close to report the lost entry, got %v", err)
+	}
+	if err := hook.Close(); err != nil {
+		t.Errorf("Second Close returned error: %v", err)
+	}
+
+	// Without a spool Flush and Close do nothing
+	plain := NewNatsHook(conn, "logs")
+	if plain.Flush(context.Background()) != nil || plain.Close() != nil || plain.SpoolStats() != (SpoolStats{}) {
+		t.Error("Expected no-op Flush, Close and SpoolStats without a spool")
+	}
+}
+
+func TestNatsHookSpoolServerRestart(t *testing.T) {
+	opts := &server.Options{Host: "127.0.0.1", Port: -1, NoLog: true, NoSigs: true}
+	srv, err := server.NewServer(opts)
+	if err != nil {
+		t.Fatalf("NewServer returned error: %v", err)
+	}
+	srv.Start()
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatal("NATS server did not start")
+	}
+	url := srv.ClientURL()
+	port := srv.Addr().(*net.TCPAddr).Port
+
+	nc, err := nats.Connect(url, nats.MaxReconnects(-1), nats.ReconnectWait(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Connect returned error: %v", err)
+	}
+	defer nc.Close()
+
+	// Replays are triggered by Flush below, once the subscriber is ready
+	hook := NewNatsHook(nc, "logs.app").WithSpool(SpoolOptions{RetryInterval: time.Hour})
+	log := New(Options{Writer: &bytes.Buffer{}, Level: InfoLevel})
+	log.AddHook(hook)
+
+	srv.Shutdown()
+	srv.WaitForShutdown()
+	for nc.IsConnected() {
+		time.Sleep(5 * time.Millisecond)
+	}
+	for i := 0; i < 3; i++ {
+		log.Info().Int("i", i).Msg("while down")
+	}
+	if stats := hook.SpoolStats(); stats.Spooled != 3 || stats.Pending != 3 {
+		t.Fatalf("Expected 3 spooled entries, got %+v", stats)
+	}
+
+	// Restart on the same port
+	opts.Port = port
+	srv, err = server.NewServer(opts)
+	if err != nil {
+		t.Fatalf("NewServer returned error: %v", err)
+	}
+	srv.Start()
+	defer srv.Shutdown()
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatal("NATS server did not restart")
+	}
+
+	sub, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatalf("Connect returned error: %v", err)
+	}
+	defer sub.Close()
+	msgs, err := sub.SubscribeSync("logs.>")
+	if err != nil {
+		t.Fatalf("SubscribeSync returned error: %v", err)
+	}
+	_ = sub.Flush()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !nc.IsConnected() {
+		if time.Now().After(deadline) {
+			t.Fatal("Connection did not reconnect")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if err := log.Sync(); err != nil {
+		t.Fatalf("Sync returned error: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		msg, err := msgs.NextMsg(2 * time.Second)
+		if err != nil {
+			t.Fatalf("Expected replayed entry %d: %v", i, err)
+		}
+		if want := fmt.Sprintf(`"i":%d`, i); !strings.Contains(string(msg.Data), want) {
+			t.Errorf("Expected entry %d in order, got %s", i, msg.Data)
+		}
+	}
+	if stats := hook.SpoolStats(); stats.Replayed != 3 || stats.Pending != 0 {
+		t.Errorf("Expected 3 replayed entries, got %+v", stats)
+	}
+}
@@ -0,0 +1,135 @@
+package pdalog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// missingSubjectToken replaces placeholders whose field is missing and that have no default
+const missingSubjectToken = "unknown"
+
+// subjectPart is a literal piece of a subject template or a placeholder
+type subjectPart struct {
+	literal string
+	// key is the field of a placeholder, empty for literals
+	key string
+	// def is the sanitized token used when the field is missing or empty
+	def string
+}
+
+// subjectTemplate is a compiled NATS subject such as "logs.{level}.{component|unknown}"
+type subjectTemplate struct {
+	parts []subjectPart
+	// static is set when the template has no placeholders
+	static bool
+}
+
+// compileSubject parses the placeholders of a subject template. A brace
+// without a matching closing brace is kept literally.
+func compileSubject(subject string) subjectTemplate {
+	var t subjectTemplate
+	for subject != "" {
+		start := strings.IndexByte(subject, '{')
+		end := -1
+		if start >= 0 {
+			end = strings.IndexByte(subject[start:], '}')
+		}
+		if start < 0 || end < 0 {
+			t.parts = append(t.parts, subjectPart{literal: subject})
+			break
+		}
+		end += start
+
+		if start > 0 {
+			t.parts = append(t.parts, subjectPart{literal: subject[:start]})
+		}
+		key, def, _ := strings.Cut(subject[start+1:end], "|")
+		key = strings.TrimSpace(key)
+		def = sanitizeSubjectToken(strings.TrimSpace(def))
+		if def == "" {
+			def = missingSubjectToken
+		}
+		t.parts = append(t.parts, subjectPart{key: key, def: def})
+		subject = subject[end+1:]
+	}
+
+	t.static = true
+	for _, p := range t.parts {
+		if p.key != "" {
+			t.static = false
+		}
+	}
+	return t
+}
+
+// render builds the subject for an entry. Field values of any type are
+// formatted and sanitized so they form a single subject token.
+func (t subjectTemplate) render(entry map[string]interface{}) string {
+	if t.static && len(t.parts) == 1 {
+		return t.parts[0].literal
+	}
+
+	var b strings.Builder
+	for _, p := range t.parts {
+		if p.key == "" {
+			b.WriteString(p.literal)
+			continue
+		}
+		token := ""
+		if val, ok := entry[p.key]; ok && val != nil {
+			token = sanitizeSubjectToken(formatFieldValue(val))
+		}
+		if token == "" {
+			token = p.def
+		}
+		b.WriteString(token)
+	}
+	return b.String()
+}
+
+// sanitizeSubjectToken replaces the characters that would split a subject
+// token or turn it into a wildcard with '_'
+func sanitizeSubjectToken(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '.', r == '*', r == '>', r <= ' ', r == 0x7f:
+			return '_'
+		}
+		return r
+	}, s)
+}
+
+// formatFieldValue renders an entry value as text for subjects and headers
+func formatFieldValue(val interface{}) string {
+	switch v := val.(type) {
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case int32:
+		return strconv.FormatInt(int64(v), 10)
+	case uint:
+		return strconv.FormatUint(uint64(v), 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case uint32:
+		return strconv.FormatUint(uint64(v), 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	}
+	return fmt.Sprint(val)
+}
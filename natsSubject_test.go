@@ -0,0 +1,71 @@
+package pdalog
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSubjectTemplate(t *testing.T) {
+	entry := map[string]interface{}{
+		"level":     "info",
+		"component": "a.b c",
+		"tenant":    "*",
+		"stream":    ">",
+		"status":    404,
+		"cached":    true,
+		"ratio":     0.5,
+		"elapsed":   1500 * time.Millisecond,
+		"err":       errors.New("no route"),
+		"empty":     "",
+	}
+
+	tests := []struct {
+		template string
+		expected string
+	}{
+		{"logs", "logs"},
+		{"logs.{level}", "logs.info"},
+		{"logs.{level}.{component}", "logs.info.a_b_c"},
+		{"logs.{tenant}.{stream}", "logs._._"},
+		{"http.{status}.{cached}", "http.404.true"},
+		{"m.{ratio}.{elapsed}", "m.0_5.1_5s"},
+		{"errors.{err}", "errors.no_route"},
+		{"logs.{missing}", "logs.unknown"},
+		{"logs.{missing|other}", "logs.other"},
+		{"logs.{empty|none}", "logs.none"},
+		{"logs.{ level | a.b }", "logs.info"},
+		{"logs.{missing|a.b}", "logs.a_b"},
+		{"logs.{level", "logs.{level"},
+		{"logs.{level}x{level}", "logs.infoxinfo"},
+	}
+
+	for _, tt := range tests {
+		if got := compileSubject(tt.template).render(entry); got != tt.expected {
+			t.Errorf("render(%q) = %q, expected %q", tt.template, got, tt.expected)
+		}
+	}
+}
+
+func TestFormatFieldValue(t *testing.T) {
+	tests := []struct {
+		val      interface{}
+		expected string
+	}{
+		{"text", "text"},
+		{42, "42"},
+		{int64(-7), "-7"},
+		{uint32(7), "7"},
+		{float32(1.25), "1.25"},
+		{false, "false"},
+		{time.Date(2025, 8, 4, 21, 2, 0, 0, time.UTC), "2025-08-04T21:02:00Z"},
+		{time.Second, "1s"},
+		{[]int{1, 2}, "[1 2]"},
+	}
+
+	for _, tt := range tests {
+		if got := formatFieldValue(tt.val); got != tt.expected {
+			t.Errorf("formatFieldValue(%v) = %q, expected %q", tt.val, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package pdalog
+
+import (
+	"io"
+	"math"
+	"os"
+	"reflect"
+	"sync"
+)
+
+// Output is a destination of a logger configured with Options.Outputs
+type Output struct {
+	// Writer receives the log lines, os.Stdout when nil
+	Writer io.Writer
+	// Level is the minimum level written to this output. Events below the
+	// logger's own level are never built, so the logger's level must not be
+	// above the lowest output level.
+	Level Level
+	// Encoder renders the lines for this output, JSONEncoder when nil
+	Encoder Encoder
+}
+
+// output is a destination of a logger with its own write lock
+type output struct {
+	// mu serializes writes so lines from parent and child never interleave,
+	// it is shared by outputs with the same writer
+	mu     *sync.Mutex
+	writer io.Writer
+	level  Level
+	// encoder is the index of the output's encoder in loggerCore.encoders
+	encoder int
+}
+
+// allLevels is the output level of Options.Writer, which receives every event
+// the logger's level lets through
+const allLevels = Level(math.MinInt8)
+
+// newOutputs creates the outputs of a logger and the distinct encoders they
+// use. Without Options.Outputs the logger writes to Options.Writer using
+// Options.Encoder.
+func newOutputs(opts Options) ([]*output, []Encoder) {
+	configs := opts.Outputs
+	if len(configs) == 0 {
+		configs = []Output{{Writer: opts.Writer, Level: allLevels, Encoder: opts.Encoder}}
+	}
+
+	var encoders []Encoder
+	outputs := make([]*output, 0, len(configs))
+	for _, cfg := range configs {
+		if cfg.Writer == nil {
+			cfg.Writer = os.Stdout
+		}
+		if cfg.Encoder == nil {
+			cfg.Encoder = JSONEncoder{}
+		}
+
+		// Outputs sharing an encoder share the encoded fields as well
+		index := -1
+		for i, enc := range encoders {
+			if sameValue(enc, cfg.Encoder) {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			index = len(encoders)
+			encoders = append(encoders, cfg.Encoder)
+		}
+
+		out := &output{mu: &sync.Mutex{}, writer: cfg.Writer, level: cfg.Level, encoder: index}
+		for _, prev := range outputs {
+			if sameValue(prev.writer, out.writer) {
+				out.mu = prev.mu
+				break
+			}
+		}
+		outputs = append(outputs, out)
+	}
+	return outputs, encoders
+}
+
+// sameValue reports whether a and b are equal, treating values of
+// non-comparable types as different
+func sameValue(a, b interface{}) bool {
+	ta, tb := reflect.TypeOf(a), reflect.TypeOf(b)
+	return ta == tb && ta != nil && ta.Comparable() && a == b
+}
+
+// wantsEncoder reports whether an output using the encoder at index i accepts the level
+func (c *loggerCore) wantsEncoder(i int, level Level) bool {
+	for _, out := range c.outputs {
+		if out.encoder == i && level >= out.level {
+			return true
+		}
+	}
+	return false
+}
+
+// write writes the line to the output, reporting failures to the error handler
+func (c *loggerCore) write(out *output, line []byte) {
+	out.mu.Lock()
+	_, err := out.writer.Write(line)
+	out.mu.Unlock()
+	if err != nil {
+		c.stats.dropped.Add(1)
+		entry := append([]byte(nil), line...)
+		c.errorHandler(&WriteError{Writer: out.writer, Entry: entry, Err: err})
+		return
+	}
+	c.stats.written.Add(1)
+}
+
+// sharesWriter reports whether an earlier output has the same writer as the
+// output at index i, so the writer is flushed and closed only once
+func (c *loggerCore) sharesWriter(i int) bool {
+	for _, out := range c.outputs[:i] {
+		if sameValue(out.writer, c.outputs[i].writer) {
+			return true
+		}
+	}
+	return false
+}
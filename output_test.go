@@ -0,0 +1,108 @@
+package pdalog
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOutputsLevelsAndEncoders(t *testing.T) {
+	timeNow = func() time.Time { return time.Date(2025, 8, 4, 21, 2, 0, 0, time.UTC) }
+	defer func() { timeNow = time.Now }()
+
+	var console, file, alerts bytes.Buffer
+	log := New(Options{
+		Level: DebugLevel,
+		Outputs: []Output{
+			{Writer: &console, Level: DebugLevel, Encoder: LogfmtEncoder{}},
+			{Writer: &file, Level: InfoLevel},
+			{Writer: &alerts, Level: ErrorLevel},
+		},
+	})
+	reqLog := log.WithFields().Str("request_id", "abc").Logger()
+
+	reqLog.Debug().Int("rows", 3).Msg("query done")
+	reqLog.Error().Str("error", "timeout").Msg("request failed")
+
+	expectedConsole := "time=2025-08-04T21:02:00Z level=debug message=\"query done\" request_id=abc rows=3\n" +
+		"time=2025-08-04T21:02:00Z level=error message=\"request failed\" request_id=abc error=timeout\n"
+	if console.String() != expectedConsole {
+		t.Errorf("Expected console output %q, got %q", expectedConsole, console.String())
+	}
+
+	expectedJSON := `{"time":"2025-08-04T21:02:00Z","level":"error","message":"request failed","request_id":"abc","error":"timeout"}` + "\n"
+	if file.String() != expectedJSON {
+		t.Errorf("Expected file output %q, got %q", expectedJSON, file.String())
+	}
+	if alerts.String() != expectedJSON {
+		t.Errorf("Expected alerts output %q, got %q", expectedJSON, alerts.String())
+	}
+
+	if stats := log.Stats(); stats.Written != 4 {
+		t.Errorf("Expected 4 written lines, got %d", stats.Written)
+	}
+}
+
+func TestOutputsLoggerLevelApplies(t *testing.T) {
+	var buf bytes.Buffer
+	log := New(Options{
+		Level:   WarnLevel,
+		Outputs: []Output{{Writer: &buf, Level: DebugLevel}},
+	})
+
+	log.Info().Msg("filtered by the logger")
+	if buf.Len() != 0 {
+		t.Errorf("Expected the logger level to filter the event, got %q", buf.String())
+	}
+}
+
+func TestOutputsFailingOutput(t *testing.T) {
+	recorder := &errorRecorder{}
+	var buf bytes.Buffer
+	log := New(Options{
+		Outputs:      []Output{{Writer: failingWriter{}}, {Writer: &buf}},
+		ErrorHandler: recorder.handle,
+	})
+
+	log.Info().Msg("still delivered")
+
+	if !strings.Contains(buf.String(), `"message":"still delivered"`) {
+		t.Errorf("Expected the healthy output to receive the line, got %q", buf.String())
+	}
+	if len(recorder.errors) != 1 {
+		t.Fatalf("Expected 1 error, got %d", len(recorder.errors))
+	}
+	var writeErr *WriteError
+	if !errors.As(recorder.errors[0], &writeErr) || writeErr.Writer != (failingWriter{}) {
+		t.Errorf("Expected a WriteError for the failing output, got %v", recorder.errors[0])
+	}
+	if stats := log.Stats(); stats.Written != 1 || stats.Dropped != 1 {
+		t.Errorf("Expected 1 written and 1 dropped line, got %+v", stats)
+	}
+}
+
+func TestOutputsSyncAndClose(t *testing.T) {
+	shared := &closeRecorder{}
+	other := &closeRecorder{}
+	log := New(Options{
+		Outputs: []Output{
+			{Writer: shared, Level: DebugLevel},
+			{Writer: shared, Level: ErrorLevel, Encoder: LogfmtEncoder{}},
+			{Writer: other},
+		},
+	})
+
+	log.Error().Msg("twice")
+	if got := strings.Count(shared.String(), "twice"); got != 2 {
+		t.Errorf("Expected the shared writer to receive 2 lines, got %d", got)
+	}
+
+	if err := log.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	if !shared.synced || !shared.closed || !other.synced || !other.closed {
+		t.Error("Expected every output to be synced and closed")
+	}
+}
@@ -0,0 +1,114 @@
+// Package pdalogotel correlates pdalog events with OpenTelemetry traces.
+//
+// TraceExtractor adds the IDs of the active span to events passed a context
+// with Event.Ctx, and SpanEventHook records log entries as events on that span:
+//
+//	log := pdalog.New(pdalog.Options{
+//		ContextExtractors: []pdalog.ContextExtractor{pdalogotel.TraceExtractor()},
+//	})
+//	log.AddHook(pdalogotel.NewSpanEventHook(pdalog.WarnLevel, pdalog.ErrorLevel))
+//
+//	log.Info().Ctx(ctx).Msg("request handled")
+package pdalogotel
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pdat-cz/go-pda-log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Field names used for the trace correlation fields
+const (
+	TraceIDKey = "trace_id"
+	SpanIDKey  = "span_id"
+)
+
+// TraceExtractor returns a context extractor adding the trace_id and span_id
+// of the span active in the event's context. Contexts without a valid span
+// are skipped.
+func TraceExtractor() pdalog.ContextExtractor {
+	return func(ctx context.Context, e *pdalog.Event) {
+		sc := trace.SpanContextFromContext(ctx)
+		if !sc.IsValid() {
+			return
+		}
+		e.Str(TraceIDKey, sc.TraceID().String()).
+			Str(SpanIDKey, sc.SpanID().String())
+	}
+}
+
+// SpanEventHook records log entries as events on the span active in the
+// event's context. The message becomes the span event name and the remaining
+// fields its attributes. Entries without a recording span are ignored.
+type SpanEventHook struct {
+	levels []pdalog.Level
+}
+
+// NewSpanEventHook creates a new span event hook. Without levels it fires for
+// all levels known when it is created, see pdalog.AllLevels.
+func NewSpanEventHook(levels ...pdalog.Level) *SpanEventHook {
+	if len(levels) == 0 {
+		levels = pdalog.AllLevels()
+	}
+	return &SpanEventHook{levels: levels}
+}
+
+// Fire is a no-op, a span can only be found through the event's context
+func (h *SpanEventHook) Fire(entry map[string]interface{}) error {
+	return nil
+}
+
+// FireContext adds the entry as an event to the span active in ctx
+func (h *SpanEventHook) FireContext(ctx context.Context, entry map[string]interface{}) error {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return nil
+	}
+
+	name, _ := entry["message"].(string)
+	attrs := make([]attribute.KeyValue, 0, len(entry))
+	for key, value := range entry {
+		switch key {
+		case "message", "time", TraceIDKey, SpanIDKey:
+			continue
+		}
+		attrs = append(attrs, attributeFor(key, value))
+	}
+	sort.Slice(attrs, func(i, j int) bool { return attrs[i].Key < attrs[j].Key })
+
+	span.AddEvent(name, trace.WithAttributes(attrs...))
+	return nil
+}
+
+// Levels returns the log levels this hook should be triggered for
+func (h *SpanEventHook) Levels() []pdalog.Level {
+	return h.levels
+}
+
+// attributeFor converts an entry value into a span attribute
+func attributeFor(key string, value interface{}) attribute.KeyValue {
+	switch v := value.(type) {
+	case string:
+		return attribute.String(key, v)
+	case int:
+		return attribute.Int(key, v)
+	case int64:
+		return attribute.Int64(key, v)
+	case bool:
+		return attribute.Bool(key, v)
+	case float64:
+		return attribute.Float64(key, v)
+	case time.Duration:
+		return attribute.Int64(key, int64(v))
+	case time.Time:
+		return attribute.String(key, v.Format(time.RFC3339Nano))
+	case fmt.Stringer:
+		return attribute.String(key, v.String())
+	}
+	return attribute.String(key, fmt.Sprint(value))
+}
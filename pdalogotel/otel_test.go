@@ -0,0 +1,94 @@
+package pdalogotel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/pdat-cz/go-pda-log"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newTracer(t *testing.T) (*tracetest.InMemoryExporter, *sdktrace.TracerProvider) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	t.Cleanup(func() { _ = provider.Shutdown(context.Background()) })
+	return exporter, provider
+}
+
+func TestTraceExtractor(t *testing.T) {
+	_, provider := newTracer(t)
+	ctx, span := provider.Tracer("test").Start(context.Background(), "request")
+	defer span.End()
+
+	buf := &bytes.Buffer{}
+	log := pdalog.New(pdalog.Options{
+		Writer:            buf,
+		Level:             pdalog.DebugLevel,
+		ContextExtractors: []pdalog.ContextExtractor{TraceExtractor()},
+	})
+
+	log.Info().Ctx(ctx).Msg("inside span")
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Failed to parse JSON: %v", err)
+	}
+
+	sc := span.SpanContext()
+	if entry[TraceIDKey] != sc.TraceID().String() {
+		t.Errorf("Expected trace_id to be %s, got %v", sc.TraceID(), entry[TraceIDKey])
+	}
+	if entry[SpanIDKey] != sc.SpanID().String() {
+		t.Errorf("Expected span_id to be %s, got %v", sc.SpanID(), entry[SpanIDKey])
+	}
+
+	// Events without an active span carry no trace fields
+	buf.Reset()
+	log.Info().Ctx(context.Background()).Msg("outside span")
+	if bytes.Contains(buf.Bytes(), []byte(TraceIDKey)) {
+		t.Errorf("Expected no trace_id outside a span, got %s", buf.String())
+	}
+}
+
+func TestSpanEventHook(t *testing.T) {
+	exporter, provider := newTracer(t)
+	ctx, span := provider.Tracer("test").Start(context.Background(), "request")
+
+	log := pdalog.New(pdalog.Options{
+		Writer: &bytes.Buffer{},
+		Level:  pdalog.DebugLevel,
+	})
+	log.AddHook(NewSpanEventHook(pdalog.WarnLevel))
+
+	log.Info().Ctx(ctx).Msg("not recorded")
+	log.Warn().Ctx(ctx).Str("component", "db").Int("retries", 3).Msg("slow query")
+	log.Warn().Msg("no span in context")
+	span.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 exported span, got %d", len(spans))
+	}
+	events := spans[0].Events
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 span event, got %d", len(events))
+	}
+	if events[0].Name != "slow query" {
+		t.Errorf("Expected span event name to be 'slow query', got %q", events[0].Name)
+	}
+
+	attrs := attribute.NewSet(events[0].Attributes...)
+	if v, _ := attrs.Value("component"); v.AsString() != "db" {
+		t.Errorf("Expected component attribute to be 'db', got %v", v.Emit())
+	}
+	if v, _ := attrs.Value("retries"); v.AsInt64() != 3 {
+		t.Errorf("Expected retries attribute to be 3, got %v", v.Emit())
+	}
+	if v, _ := attrs.Value("level"); v.AsString() != "warn" {
+		t.Errorf("Expected level attribute to be 'warn', got %v", v.Emit())
+	}
+}
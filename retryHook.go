@@ -0,0 +1,102 @@
+package pdalog
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// RetryOptions configures a RetryHook
+type RetryOptions struct {
+	// MaxRetries is the number of retries after the first failed attempt, 3 when zero
+	MaxRetries int
+	// InitialBackoff is the wait before the first retry, 100ms when zero
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between retries, which doubles after every attempt, 5s when zero
+	MaxBackoff time.Duration
+}
+
+// RetryHook wraps a Hook and retries failed fires with exponential backoff.
+// Retries run in the goroutine firing the hook, so wrap the RetryHook in an
+// AsyncHook to keep them off the logging path.
+type RetryHook struct {
+	hook Hook
+	opts RetryOptions
+}
+
+// NewRetryHook wraps the hook with retries
+func NewRetryHook(hook Hook, opts RetryOptions) *RetryHook {
+	return &RetryHook{hook: hook, opts: opts.withDefaults()}
+}
+
+// withDefaults returns the options with zero values replaced by defaults
+func (o RetryOptions) withDefaults() RetryOptions {
+	if o.MaxRetries <= 0 {
+		o.MaxRetries = 3
+	}
+	if o.InitialBackoff <= 0 {
+		o.InitialBackoff = 100 * time.Millisecond
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = 5 * time.Second
+	}
+	return o
+}
+
+// wait sleeps for backoff and returns the doubled backoff capped at
+// MaxBackoff, reporting false when ctx is done first
+func (o RetryOptions) wait(ctx context.Context, backoff time.Duration) (time.Duration, bool) {
+	timer := time.NewTimer(backoff)
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		timer.Stop()
+		return backoff, false
+	}
+	return min(backoff*2, o.MaxBackoff), true
+}
+
+// Fire fires the wrapped hook, retrying on failure
+func (h *RetryHook) Fire(entry map[string]interface{}) error {
+	return h.FireContext(context.Background(), entry)
+}
+
+// FireContext fires the wrapped hook, retrying on failure until the retries
+// are exhausted or ctx is done. The last error is returned.
+func (h *RetryHook) FireContext(ctx context.Context, entry map[string]interface{}) error {
+	backoff := h.opts.InitialBackoff
+	for attempt := 0; ; attempt++ {
+		var err error
+		if ch, ok := h.hook.(ContextHook); ok {
+			err = ch.FireContext(ctx, entry)
+		} else {
+			err = h.hook.Fire(entry)
+		}
+		if err == nil || attempt == h.opts.MaxRetries {
+			return err
+		}
+
+		var ok bool
+		if backoff, ok = h.opts.wait(ctx, backoff); !ok {
+			return err
+		}
+	}
+}
+
+// Levels returns the levels of the wrapped hook
+func (h *RetryHook) Levels() []Level {
+	return h.hook.Levels()
+}
+
+// Flush flushes the wrapped hook if it buffers entries
+func (h *RetryHook) Flush(ctx context.Context) error {
+	return flush(ctx, h.hook)
+}
+
+// Close closes the wrapped hook if it implements io.Closer
+func (h *RetryHook) Close() error {
+	if c, ok := h.hook.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,440 @@
+package pdalog
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// backupTimeFormat is the layout of the timestamp in rotated file names
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// compressSuffix is appended to the names of compressed backups
+const compressSuffix = ".gz"
+
+// renameFile renames the active file to its backup name, replaceable in tests
+var renameFile = os.Rename
+
+// RotateInterval selects time-based rotation for a RotatingFile
+type RotateInterval int
+
+const (
+	// RotateNever disables time-based rotation
+	RotateNever RotateInterval = iota
+	// RotateHourly rotates at the start of every hour
+	RotateHourly
+	// RotateDaily rotates at midnight local time
+	RotateDaily
+)
+
+// RotatingFileOptions configures a RotatingFile
+type RotatingFileOptions struct {
+	// Filename is the path of the active log file, parent directories are created
+	Filename string
+	// MaxSize is the size in bytes at which the file is rotated, 0 disables it
+	MaxSize int64
+	// MaxAge removes backups older than this, 0 keeps them regardless of age
+	MaxAge time.Duration
+	// MaxBackups is the number of backups to keep, 0 keeps all of them
+	MaxBackups int
+	// Compress gzips rotated files
+	Compress bool
+	// Interval enables rotation at the start of every hour or day
+	Interval RotateInterval
+	// FileMode is used when creating files, 0644 when zero
+	FileMode os.FileMode
+}
+
+// RotatingFile is an io.Writer writing to a file that is rotated by size or
+// time. Rotated files are renamed to name-<UTC timestamp>.ext next to the active
+// file and optionally compressed; old backups are removed according to
+// MaxBackups and MaxAge. It is safe for concurrent use and can be used as
+// Options.Writer.
+type RotatingFile struct {
+	opts RotatingFileOptions
+
+	mu sync.Mutex
+	// file is nil after a failed rotation or reopen, Write opens it again
+	file         *os.File
+	size         int64
+	nextRotation time.Time
+	closed       bool
+
+	// millCh triggers removal and compression of backups in the mill goroutine
+	millCh   chan struct{}
+	signals  chan os.Signal
+	done     chan struct{}
+	routines sync.WaitGroup
+}
+
+// NewRotatingFile opens or creates the file and returns the writer
+func NewRotatingFile(opts RotatingFileOptions) (*RotatingFile, error) {
+	if opts.Filename == "" {
+		return nil, errors.New("rotating file needs a file name")
+	}
+	if opts.FileMode == 0 {
+		opts.FileMode = 0644
+	}
+
+	r := &RotatingFile{
+		opts:   opts,
+		millCh: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+
+	r.routines.Add(1)
+	go r.mill()
+	r.triggerMill()
+	return r, nil
+}
+
+// Write writes p to the active file, rotating it first when needed
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return 0, os.ErrClosed
+	}
+	if r.file == nil {
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+	if r.shouldRotate(int64(len(p))) {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Rotate closes the active file, renames it to a backup and opens a new one
+func (r *RotatingFile) Rotate() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return os.ErrClosed
+	}
+	return r.rotate()
+}
+
+// Reopen closes and reopens the file at the configured path. Use it after
+// an external tool such as logrotate has moved the file away.
+func (r *RotatingFile) Reopen() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return os.ErrClosed
+	}
+	if r.file != nil {
+		err := r.file.Close()
+		r.file = nil
+		if err != nil {
+			return err
+		}
+	}
+	return r.open()
+}
+
+// ReopenOnSignal calls Reopen whenever one of the signals is received,
+// SIGHUP when none are given, until the file is closed
+func (r *RotatingFile) ReopenOnSignal(sigs ...os.Signal) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGHUP}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed || r.signals != nil {
+		return
+	}
+	r.signals = make(chan os.Signal, 1)
+	signal.Notify(r.signals, sigs...)
+
+	r.routines.Add(1)
+	go func() {
+		defer r.routines.Done()
+		for {
+			select {
+			case <-r.signals:
+				_ = r.Reopen()
+			case <-r.done:
+				return
+			}
+		}
+	}()
+}
+
+// Sync commits the active file to stable storage
+func (r *RotatingFile) Sync() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return os.ErrClosed
+	}
+	if r.file == nil {
+		return nil
+	}
+	return r.file.Sync()
+}
+
+// Close closes the active file and waits for pending backup compression
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	if r.signals != nil {
+		signal.Stop(r.signals)
+	}
+	var err error
+	if r.file != nil {
+		err = r.file.Close()
+	}
+	r.mu.Unlock()
+
+	close(r.done)
+	r.routines.Wait()
+	return err
+}
+
+// shouldRotate reports whether writing n more bytes requires a rotation, r.mu must be held
+func (r *RotatingFile) shouldRotate(n int64) bool {
+	if !r.nextRotation.IsZero() && !timeNow().Before(r.nextRotation) {
+		return true
+	}
+	return r.opts.MaxSize > 0 && r.size > 0 && r.size+n > r.opts.MaxSize
+}
+
+// rotate moves the active file to a backup and opens a new one, r.mu must be held
+func (r *RotatingFile) rotate() error {
+	err := r.file.Close()
+	r.file = nil
+	if err == nil {
+		if err = renameFile(r.opts.Filename, r.backupName(timeNow())); os.IsNotExist(err) {
+			err = nil
+		}
+	}
+
+	// The file is opened again even if the rotation failed, so writes keep
+	// appending to it and the rotation is retried by the next write
+	if openErr := r.open(); openErr != nil {
+		return errors.Join(err, openErr)
+	}
+	if err != nil {
+		return err
+	}
+	r.triggerMill()
+	return nil
+}
+
+// open opens the file for appending and schedules the next time-based rotation, r.mu must be held
+func (r *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(r.opts.Filename), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(r.opts.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, r.opts.FileMode)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	r.file = f
+	r.size = info.Size()
+	r.nextRotation = nextRotation(timeNow(), r.opts.Interval)
+	return nil
+}
+
+// nextRotation returns the start of the interval following now
+func nextRotation(now time.Time, interval RotateInterval) time.Time {
+	switch interval {
+	case RotateHourly:
+		return time.Date(now.Year(), now.Month(), now.Day(), now.Hour()+1, 0, 0, 0, now.Location())
+	case RotateDaily:
+		return time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+	}
+	return time.Time{}
+}
+
+// backupName returns the name a file rotated at t is renamed to
+func (r *RotatingFile) backupName(t time.Time) string {
+	prefix, ext := r.nameParts()
+	t = t.UTC()
+	for {
+		// Rotations within the same millisecond must not overwrite a backup
+		name := prefix + t.Format(backupTimeFormat) + ext
+		if !fileExists(name) && !fileExists(name+compressSuffix) {
+			return name
+		}
+		t = t.Add(time.Millisecond)
+	}
+}
+
+// fileExists reports whether a file exists at path
+func fileExists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+// nameParts splits the file name into the backup prefix and the extension
+func (r *RotatingFile) nameParts() (prefix, ext string) {
+	ext = filepath.Ext(r.opts.Filename)
+	return strings.TrimSuffix(r.opts.Filename, ext) + "-", ext
+}
+
+// triggerMill asks the mill goroutine to process backups
+func (r *RotatingFile) triggerMill() {
+	select {
+	case r.millCh <- struct{}{}:
+	default:
+	}
+}
+
+// mill removes and compresses backups whenever it is triggered
+func (r *RotatingFile) mill() {
+	defer r.routines.Done()
+	for {
+		select {
+		case <-r.millCh:
+			_ = r.processBackups()
+		case <-r.done:
+			return
+		}
+	}
+}
+
+// backupFile is a rotated file found next to the active file
+type backupFile struct {
+	path      string
+	rotatedAt time.Time
+}
+
+// processBackups removes backups exceeding MaxBackups or MaxAge and
+// compresses the remaining ones when Compress is set
+func (r *RotatingFile) processBackups() error {
+	backups, err := r.backups()
+	if err != nil {
+		return err
+	}
+
+	var remove, keep []backupFile
+	cutoff := timeNow().Add(-r.opts.MaxAge)
+	for i, b := range backups {
+		switch {
+		case r.opts.MaxBackups > 0 && i >= r.opts.MaxBackups:
+			remove = append(remove, b)
+		case r.opts.MaxAge > 0 && b.rotatedAt.Before(cutoff):
+			remove = append(remove, b)
+		default:
+			keep = append(keep, b)
+		}
+	}
+
+	var errs []error
+	for _, b := range remove {
+		if err := os.Remove(b.path); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, err)
+		}
+	}
+	if r.opts.Compress {
+		for _, b := range keep {
+			if !strings.HasSuffix(b.path, compressSuffix) {
+				if err := compressFile(b.path, r.opts.FileMode); err != nil {
+					errs = append(errs, err)
+				}
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// backups lists the rotated files, newest first
+func (r *RotatingFile) backups() ([]backupFile, error) {
+	entries, err := os.ReadDir(filepath.Dir(r.opts.Filename))
+	if err != nil {
+		return nil, err
+	}
+
+	prefix, ext := r.nameParts()
+	prefix = filepath.Base(prefix)
+	var backups []backupFile
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		stamp := strings.TrimPrefix(name, prefix)
+		stamp = strings.TrimSuffix(stamp, compressSuffix)
+		if !strings.HasSuffix(stamp, ext) {
+			continue
+		}
+		rotatedAt, err := time.Parse(backupTimeFormat, strings.TrimSuffix(stamp, ext))
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backupFile{
+			path:      filepath.Join(filepath.Dir(r.opts.Filename), name),
+			rotatedAt: rotatedAt,
+		})
+	}
+
+	sort.Slice(backups, func(i, j int) bool { return backups[i].rotatedAt.After(backups[j].rotatedAt) })
+	return backups, nil
+}
+
+// compressFile gzips the file to path.gz and removes the original
+func compressFile(path string, mode os.FileMode) (err error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+compressSuffix, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = dst.Close()
+			_ = os.Remove(path + compressSuffix)
+		}
+	}()
+
+	gz := gzip.NewWriter(dst)
+	if _, err = io.Copy(gz, src); err != nil {
+		return err
+	}
+	if err = gz.Close(); err != nil {
+		return err
+	}
+	if err = dst.Close(); err != nil {
+		return err
+	}
+	if err = src.Close(); err != nil {
+		return fmt.Errorf("closing %s: %w", path, err)
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,313 @@
+package pdalog
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// setClock makes timeNow return a controllable time for the test and
+// returns a function advancing it. The clock is read by the mill goroutine.
+func setClock(t *testing.T, start time.Time) func(time.Duration) {
+	t.Helper()
+	var mu sync.Mutex
+	now := start
+	timeNow = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	t.Cleanup(func() { timeNow = time.Now })
+	return func(d time.Duration) {
+		mu.Lock()
+		now = now.Add(d)
+		mu.Unlock()
+	}
+}
+
+// backupNames lists the rotated files in dir, sorted
+func backupNames(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir returned error: %v", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.Name() != "app.log" {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// waitForBackups waits until the mill goroutine has produced the expected backups
+func waitForBackups(t *testing.T, dir string, check func([]string) bool) []string {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		names := backupNames(t, dir)
+		if check(names) {
+			return names
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Backups did not reach the expected state, got %v", names)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestRotatingFileMaxSize(t *testing.T) {
+	dir := t.TempDir()
+	advance := setClock(t, time.Date(2025, 8, 4, 21, 2, 0, 0, time.UTC))
+	r, err := NewRotatingFile(RotatingFileOptions{
+		Filename:   filepath.Join(dir, "app.log"),
+		MaxSize:    10,
+		MaxBackups: 2,
+	})
+	if err != nil {
+		t.Fatalf("NewRotatingFile returned error: %v", err)
+	}
+	defer r.Close()
+
+	for _, line := range []string{"aaaaaaaa\n", "bbbbbbbb\n", "cccccccc\n", "dddddddd\n"} {
+		if _, err := r.Write([]byte(line)); err != nil {
+			t.Fatalf("Write returned error: %v", err)
+		}
+		advance(time.Second)
+	}
+
+	names := waitForBackups(t, dir, func(names []string) bool { return len(names) == 2 })
+	expected := []string{"app-2025-08-04T21-02-02.000.log", "app-2025-08-04T21-02-03.000.log"}
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Errorf("Expected backups %v, got %v", expected, names)
+			break
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "app.log"))
+	if err != nil {
+		t.Fatalf("ReadFile returned error: %v", err)
+	}
+	if string(data) != "dddddddd\n" {
+		t.Errorf("Expected active file to hold the last line, got %q", data)
+	}
+}
+
+func TestRotatingFileInterval(t *testing.T) {
+	dir := t.TempDir()
+	advance := setClock(t, time.Date(2025, 8, 4, 21, 59, 0, 0, time.UTC))
+	r, err := NewRotatingFile(RotatingFileOptions{
+		Filename: filepath.Join(dir, "app.log"),
+		Interval: RotateHourly,
+	})
+	if err != nil {
+		t.Fatalf("NewRotatingFile returned error: %v", err)
+	}
+	defer r.Close()
+
+	_, _ = r.Write([]byte("before\n"))
+	advance(30 * time.Second)
+	_, _ = r.Write([]byte("same hour\n"))
+	if names := backupNames(t, dir); len(names) != 0 {
+		t.Fatalf("Expected no rotation within the hour, got %v", names)
+	}
+
+	advance(time.Minute)
+	_, _ = r.Write([]byte("next hour\n"))
+	if names := backupNames(t, dir); len(names) != 1 || names[0] != "app-2025-08-04T22-00-30.000.log" {
+		t.Errorf("Expected one hourly rotation, got %v", names)
+	}
+}
+
+func TestRotatingFileCompressAndMaxAge(t *testing.T) {
+	dir := t.TempDir()
+	advance := setClock(t, time.Date(2025, 8, 4, 21, 2, 0, 0, time.UTC))
+
+	// An old backup from a previous run is removed by MaxAge
+	old := filepath.Join(dir, "app-2025-07-01T00-00-00.000.log")
+	if err := os.WriteFile(old, []byte("old\n"), 0644); err != nil {
+		t.Fatalf("WriteFile returned error: %v", err)
+	}
+
+	r, err := NewRotatingFile(RotatingFileOptions{
+		Filename: filepath.Join(dir, "app.log"),
+		MaxAge:   24 * time.Hour,
+		Compress: true,
+	})
+	if err != nil {
+		t.Fatalf("NewRotatingFile returned error: %v", err)
+	}
+	defer r.Close()
+
+	_, _ = r.Write([]byte("rotated content\n"))
+	advance(time.Minute)
+	if err := r.Rotate(); err != nil {
+		t.Fatalf("Rotate returned error: %v", err)
+	}
+
+	names := waitForBackups(t, dir, func(names []string) bool {
+		return len(names) == 1 && strings.HasSuffix(names[0], ".gz")
+	})
+	if names[0] != "app-2025-08-04T21-03-00.000.log.gz" {
+		t.Errorf("Unexpected compressed backup name %s", names[0])
+	}
+
+	f, err := os.Open(filepath.Join(dir, names[0]))
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("gzip.NewReader returned error: %v", err)
+	}
+	data, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatalf("ReadAll returned error: %v", err)
+	}
+	if string(data) != "rotated content\n" {
+		t.Errorf("Unexpected compressed content %q", data)
+	}
+}
+
+func TestRotatingFileSameMillisecond(t *testing.T) {
+	dir := t.TempDir()
+	setClock(t, time.Date(2025, 8, 4, 21, 2, 0, 0, time.UTC))
+	r, err := NewRotatingFile(RotatingFileOptions{Filename: filepath.Join(dir, "app.log")})
+	if err != nil {
+		t.Fatalf("NewRotatingFile returned error: %v", err)
+	}
+	defer r.Close()
+
+	for _, line := range []string{"first\n", "second\n"} {
+		_, _ = r.Write([]byte(line))
+		if err := r.Rotate(); err != nil {
+			t.Fatalf("Rotate returned error: %v", err)
+		}
+	}
+
+	names := backupNames(t, dir)
+	expected := []string{"app-2025-08-04T21-02-00.000.log", "app-2025-08-04T21-02-00.001.log"}
+	if strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Fatalf("Expected backups %v, got %v", expected, names)
+	}
+	first, _ := os.ReadFile(filepath.Join(dir, names[0]))
+	second, _ := os.ReadFile(filepath.Join(dir, names[1]))
+	if string(first) != "first\n" || string(second) != "second\n" {
+		t.Errorf("Expected both backups to be kept, got %q and %q", first, second)
+	}
+}
+
+func TestRotatingFileRecoversFromFailedRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	r, err := NewRotatingFile(RotatingFileOptions{Filename: path})
+	if err != nil {
+		t.Fatalf("NewRotatingFile returned error: %v", err)
+	}
+	defer r.Close()
+
+	renameFile = func(string, string) error { return syscall.EACCES }
+	defer func() { renameFile = os.Rename }()
+
+	_, _ = r.Write([]byte("before\n"))
+	if err := r.Rotate(); err == nil {
+		t.Fatal("Expected the rotation to fail")
+	}
+	if _, err := r.Write([]byte("after\n")); err != nil {
+		t.Fatalf("Expected writes to continue after a failed rotation, got %v", err)
+	}
+	if err := r.Sync(); err != nil {
+		t.Errorf("Sync returned error: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "before\nafter\n" {
+		t.Errorf("Expected the file to be appended to, got %q", data)
+	}
+
+	renameFile = os.Rename
+	if err := r.Rotate(); err != nil {
+		t.Fatalf("Expected the rotation to succeed again, got %v", err)
+	}
+	if names := backupNames(t, dir); len(names) != 1 {
+		t.Errorf("Expected 1 backup, got %v", names)
+	}
+}
+
+func TestRotatingFileReopen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	r, err := NewRotatingFile(RotatingFileOptions{Filename: path})
+	if err != nil {
+		t.Fatalf("NewRotatingFile returned error: %v", err)
+	}
+	defer r.Close()
+
+	_, _ = r.Write([]byte("first\n"))
+
+	// Simulate logrotate moving the file away and signalling the process
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatalf("Rename returned error: %v", err)
+	}
+	r.ReopenOnSignal()
+	r.signals <- syscall.SIGHUP
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("File was not reopened after the signal")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	_, _ = r.Write([]byte("second\n"))
+	data, _ := os.ReadFile(path)
+	if string(data) != "second\n" {
+		t.Errorf("Expected new file to hold the second line, got %q", data)
+	}
+	moved, _ := os.ReadFile(path + ".1")
+	if string(moved) != "first\n" {
+		t.Errorf("Expected moved file to hold the first line, got %q", moved)
+	}
+}
+
+func TestRotatingFileWithLogger(t *testing.T) {
+	dir := t.TempDir()
+	r, err := NewRotatingFile(RotatingFileOptions{
+		Filename: filepath.Join(dir, "logs", "app.log"),
+		MaxSize:  1 << 20,
+	})
+	if err != nil {
+		t.Fatalf("NewRotatingFile returned error: %v", err)
+	}
+
+	log := New(Options{Writer: r})
+	log.Info().Msg("to file")
+	if err := log.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "logs", "app.log"))
+	if err != nil {
+		t.Fatalf("ReadFile returned error: %v", err)
+	}
+	if !strings.Contains(string(data), `"message":"to file"`) {
+		t.Errorf("Expected the entry in the file, got %q", data)
+	}
+	if _, err := r.Write([]byte("x")); err == nil {
+		t.Error("Expected Write to fail after the logger closed the file")
+	}
+}
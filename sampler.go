@@ -0,0 +1,234 @@
+package pdalog
+
+import (
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Sampler decides which events are logged. Samplers are consulted when an
+// event is created, before any field is encoded, so sampled-out events cost
+// almost nothing. Fatal and Panic events are never sampled.
+type Sampler interface {
+	// Sample reports whether an event at the level should be logged
+	Sample(level Level) bool
+}
+
+// EveryNSampler logs every Nth event and drops the others. N of 0 or 1 logs
+// every event.
+type EveryNSampler struct {
+	N       uint32
+	counter atomic.Uint32
+}
+
+// Sample implements Sampler
+func (s *EveryNSampler) Sample(Level) bool {
+	if s.N <= 1 {
+		return true
+	}
+	return s.counter.Add(1)%s.N == 1
+}
+
+// RandomSampler logs each event with the given probability in percent
+type RandomSampler struct {
+	Percent float64
+}
+
+// Sample implements Sampler
+func (s RandomSampler) Sample(Level) bool {
+	return rand.Float64()*100 < s.Percent
+}
+
+// BurstSampler logs the first Burst events of every Period and passes the
+// following events of the period to Next, e.g. an EveryNSampler to log 1 in
+// M of them. Without Next they are dropped.
+type BurstSampler struct {
+	Burst uint32
+	// Period is the length of the window a burst is counted in, one second
+	// when zero
+	Period time.Duration
+	Next   Sampler
+
+	counter atomic.Uint32
+	// resetAt is the end of the current period in Unix nanoseconds
+	resetAt atomic.Int64
+}
+
+// Sample implements Sampler
+func (s *BurstSampler) Sample(level Level) bool {
+	if s.Burst > 0 && s.inBurst() {
+		return true
+	}
+	if s.Next == nil {
+		return false
+	}
+	return s.Next.Sample(level)
+}
+
+// inBurst counts the event against the current period
+func (s *BurstSampler) inBurst() bool {
+	period := s.Period
+	if period <= 0 {
+		period = time.Second
+	}
+	now := timeNow().UnixNano()
+	resetAt := s.resetAt.Load()
+	if now >= resetAt && s.resetAt.CompareAndSwap(resetAt, now+int64(period)) {
+		s.counter.Store(1)
+		return true
+	}
+	return s.counter.Add(1) <= s.Burst
+}
+
+// LevelSampler applies a different sampler to each level. Levels without a
+// sampler are always logged.
+type LevelSampler map[Level]Sampler
+
+// Sample implements Sampler
+func (s LevelSampler) Sample(level Level) bool {
+	if sampler, ok := s[level]; ok && sampler != nil {
+		return sampler.Sample(level)
+	}
+	return true
+}
+
+// samplingStats counts sampled-out events per level for the periodic report
+type samplingStats struct {
+	// interval between reports, no reports are written when it is not positive
+	interval time.Duration
+	// pending counts the events sampled out since the last report, per level
+	pending      [256]atomic.Uint64
+	pendingTotal atomic.Uint64
+	// nextReport is the earliest time of the next report in Unix nanoseconds
+	nextReport atomic.Int64
+
+	// mu guards starting and stopping the report goroutine, which writes the
+	// reports when no events are logged
+	mu        sync.Mutex
+	reporting atomic.Bool
+	stopped   bool
+	done      chan struct{}
+	routines  sync.WaitGroup
+}
+
+// WithSampler returns a child logger whose events are sampled by s. Pass nil
+// to log every event of the child again.
+func (l *Logger) WithSampler(s Sampler) *Logger {
+	child := l.WithFields().Logger()
+	child.sampler = s
+	child.samplerSet = true
+	return child
+}
+
+// sampled reports whether an event at the level passes the logger's
+// sampler, counting it if it does not
+func (l *Logger) sampled(level Level) bool {
+	if level == FatalLevel || level == PanicLevel {
+		return true
+	}
+	for lg := l; lg != nil; lg = lg.parent {
+		if !lg.samplerSet {
+			continue
+		}
+		if lg.sampler == nil || lg.sampler.Sample(level) {
+			return true
+		}
+		core := l.core
+		core.stats.sampledOut.Add(1)
+		core.sampling.pending[uint8(level)].Add(1)
+		core.sampling.pendingTotal.Add(1)
+		l.startSampleReports()
+		return false
+	}
+	return true
+}
+
+// startSampleReports starts the goroutine writing the reports every
+// interval, so counts are reported when events stop being logged.
+// Logger.Close stops it.
+func (l *Logger) startSampleReports() {
+	sampling := &l.core.sampling
+	if sampling.interval <= 0 || sampling.reporting.Load() {
+		return
+	}
+	sampling.mu.Lock()
+	defer sampling.mu.Unlock()
+	if sampling.reporting.Load() || sampling.stopped {
+		return
+	}
+	sampling.reporting.Store(true)
+	sampling.done = make(chan struct{})
+	sampling.routines.Add(1)
+	go func() {
+		defer sampling.routines.Done()
+		ticker := time.NewTicker(sampling.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				l.writeSampledReport()
+			case <-sampling.done:
+				return
+			}
+		}
+	}()
+}
+
+// stopSampleReports stops the report goroutine
+func (l *Logger) stopSampleReports() {
+	sampling := &l.core.sampling
+	sampling.mu.Lock()
+	if sampling.stopped {
+		sampling.mu.Unlock()
+		return
+	}
+	sampling.stopped = true
+	if sampling.reporting.Load() {
+		close(sampling.done)
+	}
+	sampling.mu.Unlock()
+	sampling.routines.Wait()
+}
+
+// reportSampled writes one entry per level summarizing the events sampled
+// out since the last report, once the report interval has passed. Reports
+// are written to the outputs only and are never sampled themselves.
+func (l *Logger) reportSampled() {
+	sampling := &l.core.sampling
+	if sampling.interval <= 0 || sampling.pendingTotal.Load() == 0 {
+		return
+	}
+	now := timeNow().UnixNano()
+	next := sampling.nextReport.Load()
+	if now < next || !sampling.nextReport.CompareAndSwap(next, now+int64(sampling.interval)) {
+		return
+	}
+	l.writeSampledEntries()
+}
+
+// writeSampledReport writes the report regardless of the time of the last
+// one, as done by the report goroutine and Logger.Sync
+func (l *Logger) writeSampledReport() {
+	sampling := &l.core.sampling
+	if sampling.interval <= 0 || sampling.pendingTotal.Load() == 0 {
+		return
+	}
+	sampling.nextReport.Store(timeNow().Add(sampling.interval).UnixNano())
+	l.writeSampledEntries()
+}
+
+// writeSampledEntries writes and resets the pending counts of each level
+func (l *Logger) writeSampledEntries() {
+	sampling := &l.core.sampling
+	for i := range sampling.pending {
+		n := sampling.pending[i].Swap(0)
+		if n == 0 {
+			continue
+		}
+		sampling.pendingTotal.Add(^(n - 1))
+		level := Level(int8(uint8(i)))
+		e := newPooledEvent(l, level)
+		e.Int("sampled_out", int(n)).Msg("events sampled out")
+	}
+}
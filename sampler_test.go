@@ -0,0 +1,247 @@
+package pdalog
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countLines returns the number of logged lines containing s
+func countLines(buf *bytes.Buffer, s string) int {
+	return strings.Count(buf.String(), s)
+}
+
+// syncWriter is a bytes.Buffer that can be read while the logger writes to it
+type syncWriter struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (w *syncWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *syncWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func TestEveryNSampler(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := New(Options{Writer: buf, Sampler: &EveryNSampler{N: 3}})
+
+	for i := 0; i < 9; i++ {
+		log.Info().Int("i", i).Msg("tick")
+	}
+
+	if got := countLines(buf, `"message":"tick"`); got != 3 {
+		t.Fatalf("Expected 3 sampled lines, got %d", got)
+	}
+	for _, i := range []string{`"i":0}`, `"i":3}`, `"i":6}`} {
+		if !strings.Contains(buf.String(), i) {
+			t.Errorf("Expected line with %s, got %s", i, buf.String())
+		}
+	}
+	if stats := log.Stats(); stats.SampledOut != 6 {
+		t.Errorf("Expected 6 sampled out events, got %d", stats.SampledOut)
+	}
+}
+
+func TestRandomSampler(t *testing.T) {
+	if (RandomSampler{Percent: 0}).Sample(InfoLevel) {
+		t.Error("Expected 0 percent to drop every event")
+	}
+	if !(RandomSampler{Percent: 100}).Sample(InfoLevel) {
+		t.Error("Expected 100 percent to log every event")
+	}
+
+	sampler := RandomSampler{Percent: 50}
+	logged := 0
+	for i := 0; i < 10000; i++ {
+		if sampler.Sample(InfoLevel) {
+			logged++
+		}
+	}
+	if logged < 4000 || logged > 6000 {
+		t.Errorf("Expected about half of the events, got %d of 10000", logged)
+	}
+}
+
+func TestBurstSampler(t *testing.T) {
+	advance := setClock(t, time.Date(2025, 8, 4, 21, 2, 0, 0, time.UTC))
+	sampler := &BurstSampler{Burst: 2, Period: time.Second, Next: &EveryNSampler{N: 3}}
+
+	var got []bool
+	for i := 0; i < 8; i++ {
+		got = append(got, sampler.Sample(WarnLevel))
+	}
+	expected := []bool{true, true, true, false, false, true, false, false}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Fatalf("Expected %v, got %v", expected, got)
+		}
+	}
+
+	// A new period starts a new burst
+	advance(time.Second)
+	if !sampler.Sample(WarnLevel) || !sampler.Sample(WarnLevel) {
+		t.Error("Expected the burst to reset after the period")
+	}
+
+	// Without Next events beyond the burst are dropped
+	dropping := &BurstSampler{Burst: 1, Period: time.Second}
+	if !dropping.Sample(WarnLevel) || dropping.Sample(WarnLevel) {
+		t.Error("Expected only the first event of the period")
+	}
+}
+
+func TestLevelSampler(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := New(Options{
+		Writer:  buf,
+		Level:   DebugLevel,
+		Sampler: LevelSampler{DebugLevel: RandomSampler{Percent: 0}, WarnLevel: &EveryNSampler{N: 2}},
+	})
+
+	for i := 0; i < 4; i++ {
+		log.Debug().Msg("debug")
+		log.Info().Msg("info")
+		log.Warn().Msg("warn")
+	}
+
+	if countLines(buf, `"message":"debug"`) != 0 || countLines(buf, `"message":"info"`) != 4 || countLines(buf, `"message":"warn"`) != 2 {
+		t.Errorf("Unexpected sampled output %s", buf.String())
+	}
+}
+
+func TestSamplerNeverDropsFatal(t *testing.T) {
+	exitCode := -1
+	buf := &bytes.Buffer{}
+	log := New(Options{Writer: buf, Sampler: RandomSampler{Percent: 0}, ExitFunc: func(code int) { exitCode = code }})
+
+	log.Fatal().Msg("fatal")
+	if exitCode != 1 || !strings.Contains(buf.String(), `"message":"fatal"`) {
+		t.Errorf("Expected the fatal event to be logged and exit, got code %d and %q", exitCode, buf.String())
+	}
+}
+
+func TestWithSampler(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := New(Options{Writer: buf})
+	noisy := log.With("component", "dns").WithSampler(RandomSampler{Percent: 0})
+	child := noisy.With("attempt", 1)
+	unsampled := noisy.WithSampler(nil)
+
+	log.Info().Msg("parent")
+	noisy.Info().Msg("noisy")
+	child.Info().Msg("child")
+	unsampled.Info().Msg("unsampled")
+
+	if countLines(buf, `"message":"parent"`) != 1 || countLines(buf, `"message":"unsampled"`) != 1 {
+		t.Errorf("Expected parent and unsampled events, got %s", buf.String())
+	}
+	if countLines(buf, `"message":"noisy"`) != 0 || countLines(buf, `"message":"child"`) != 0 {
+		t.Errorf("Expected the sampler to apply to the child and its descendants, got %s", buf.String())
+	}
+}
+
+func TestSampledReport(t *testing.T) {
+	advance := setClock(t, time.Date(2025, 8, 4, 21, 2, 0, 0, time.UTC))
+	buf := &bytes.Buffer{}
+	log := New(Options{Writer: buf, Sampler: LevelSampler{WarnLevel: &EveryNSampler{N: 10}}, SampleReportInterval: time.Second})
+
+	for i := 0; i < 25; i++ {
+		log.Warn().Msg("disk slow")
+	}
+	if strings.Contains(buf.String(), "events sampled out") {
+		t.Fatalf("Expected no report before the interval, got %s", buf.String())
+	}
+
+	advance(time.Second)
+	log.Info().Msg("next")
+
+	expected := `{"time":"2025-08-04T21:02:01Z","level":"warn","message":"events sampled out","sampled_out":22}`
+	if !strings.Contains(buf.String(), expected) {
+		t.Errorf("Expected report %s, got %s", expected, buf.String())
+	}
+
+	// Counts are reset after a report
+	advance(time.Second)
+	log.Info().Msg("again")
+	if countLines(buf, "events sampled out") != 1 {
+		t.Errorf("Expected a single report, got %s", buf.String())
+	}
+
+	// Reports can be disabled
+	quiet := &bytes.Buffer{}
+	log = New(Options{Writer: quiet, Sampler: &EveryNSampler{N: 2}, SampleReportInterval: -1})
+	for i := 0; i < 4; i++ {
+		log.Info().Msg("tick")
+	}
+	advance(time.Hour)
+	log.Info().Msg("tick")
+	if strings.Contains(quiet.String(), "events sampled out") {
+		t.Errorf("Expected no report, got %s", quiet.String())
+	}
+}
+
+func TestSampledReportWithoutTraffic(t *testing.T) {
+	buf := &syncWriter{}
+	log := New(Options{Writer: buf, Sampler: &EveryNSampler{N: 2}, SampleReportInterval: 20 * time.Millisecond})
+	defer log.Close()
+
+	for i := 0; i < 4; i++ {
+		log.Info().Msg("tick")
+	}
+
+	// The report is written although no further events are logged
+	deadline := time.Now().Add(2 * time.Second)
+	for !strings.Contains(buf.String(), `"sampled_out":2`) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected a report without further events, got %s", buf.String())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSampledReportOnSyncAndClose(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := New(Options{Writer: buf, Sampler: &EveryNSampler{N: 2}})
+
+	for i := 0; i < 4; i++ {
+		log.Info().Msg("tick")
+	}
+	if err := log.Sync(); err != nil {
+		t.Fatalf("Sync returned error: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"message":"events sampled out","sampled_out":2`) {
+		t.Errorf("Expected Sync to write the report, got %s", buf.String())
+	}
+
+	log.Info().Msg("tick")
+	log.Info().Msg("tick")
+	if err := log.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	if countLines(buf, "events sampled out") != 2 {
+		t.Errorf("Expected Close to write the pending report, got %s", buf.String())
+	}
+}
+
+func TestBurstSamplerDefaultPeriod(t *testing.T) {
+	advance := setClock(t, time.Date(2025, 8, 4, 21, 2, 0, 0, time.UTC))
+	sampler := &BurstSampler{Burst: 1}
+
+	if !sampler.Sample(InfoLevel) || sampler.Sample(InfoLevel) {
+		t.Error("Expected only the first event of the default period")
+	}
+	advance(time.Second)
+	if !sampler.Sample(InfoLevel) {
+		t.Error("Expected the burst to reset after one second")
+	}
+}
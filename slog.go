@@ -0,0 +1,163 @@
+package pdalog
+
+import (
+	"context"
+	"log/slog"
+)
+
+// SlogHandler is a slog.Handler that routes log/slog records into a Logger,
+// so records logged through slog reach the Logger's writer and hooks.
+// Attributes inside groups are flattened into dotted keys, e.g. "http.method".
+type SlogHandler struct {
+	logger *Logger
+	prefix string
+}
+
+// NewSlogHandler creates a slog.Handler writing to the given logger
+func NewSlogHandler(l *Logger) *SlogHandler {
+	return &SlogHandler{logger: l}
+}
+
+// Enabled reports whether the logger's level allows records at the given level
+func (h *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return SlogLevel(level) >= h.logger.GetLevel()
+}
+
+// Handle converts the record into an Event and sends it. The context is
+// attached with Event.Ctx, so context extractors and context hooks apply to
+// records logged with slog.InfoContext and the like.
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	e := h.logger.newEvent(SlogLevel(r.Level))
+	if e == nil {
+		return nil
+	}
+	if !r.Time.IsZero() {
+		e.time = r.Time
+	}
+	e.Ctx(ctx)
+	r.Attrs(func(a slog.Attr) bool {
+		appendSlogAttr(e, h.prefix, a)
+		return true
+	})
+	e.Msg(r.Message)
+	return nil
+}
+
+// WithAttrs returns a handler whose logger carries the attributes as context
+// fields, collected into a single child logger
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	c := h.logger.WithFields()
+	for _, a := range attrs {
+		addSlogAttr(c, h.prefix, a)
+	}
+	return &SlogHandler{logger: c.Logger(), prefix: h.prefix}
+}
+
+// WithGroup returns a handler that prefixes subsequent attribute keys with the group name
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &SlogHandler{logger: h.logger, prefix: h.prefix + name + "."}
+}
+
+// SlogLevel maps a slog level to the closest Level. Levels below
+// slog.LevelDebug map to TraceLevel and levels above
+// slog.LevelError map to ErrorLevel, so slog never triggers a Fatal exit.
+func SlogLevel(level slog.Level) Level {
+	switch {
+	case level < slog.LevelDebug:
+		return TraceLevel
+	case level < slog.LevelInfo:
+		return DebugLevel
+	case level < slog.LevelWarn:
+		return InfoLevel
+	case level < slog.LevelError:
+		return WarnLevel
+	default:
+		return ErrorLevel
+	}
+}
+
+// appendSlogAttr adds the attribute to the event using the matching field method
+func appendSlogAttr(e *Event, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+
+	v := a.Value
+	if v.Kind() == slog.KindGroup {
+		groupPrefix := prefix
+		if a.Key != "" {
+			groupPrefix += a.Key + "."
+		}
+		for _, ga := range v.Group() {
+			appendSlogAttr(e, groupPrefix, ga)
+		}
+		return
+	}
+
+	key := prefix + a.Key
+	switch v.Kind() {
+	case slog.KindString:
+		e.Str(key, v.String())
+	case slog.KindInt64:
+		e.Int(key, int(v.Int64()))
+	case slog.KindBool:
+		e.Bool(key, v.Bool())
+	case slog.KindDuration:
+		e.Duration(key, v.Duration())
+	case slog.KindTime:
+		e.Time(key, v.Time())
+	default:
+		if err, ok := v.Any().(error); ok {
+			e.Str(key, err.Error())
+			return
+		}
+		e.Any(key, v.Any())
+	}
+}
+
+// addSlogAttr adds the attribute to the builder using the matching field method
+func addSlogAttr(c *ContextBuilder, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+
+	v := a.Value
+	if v.Kind() == slog.KindGroup {
+		groupPrefix := prefix
+		if a.Key != "" {
+			groupPrefix += a.Key + "."
+		}
+		for _, ga := range v.Group() {
+			addSlogAttr(c, groupPrefix, ga)
+		}
+		return
+	}
+
+	key := prefix + a.Key
+	switch v.Kind() {
+	case slog.KindString:
+		c.Str(key, v.String())
+	case slog.KindInt64:
+		c.Int(key, int(v.Int64()))
+	case slog.KindBool:
+		c.Bool(key, v.Bool())
+	case slog.KindDuration:
+		c.Duration(key, v.Duration())
+	case slog.KindTime:
+		c.Time(key, v.Time())
+	default:
+		if err, ok := v.Any().(error); ok {
+			c.Str(key, err.Error())
+			return
+		}
+		c.Any(key, v.Any())
+	}
+}
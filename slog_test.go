@@ -0,0 +1,183 @@
+package pdalog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlogHandler(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := New(Options{
+		Writer: buf,
+		Level:  DebugLevel,
+	})
+	logger := slog.New(NewSlogHandler(log))
+
+	logger.Warn("disk almost full",
+		slog.String("mount", "/var"),
+		slog.Int("percent", 93),
+		slog.Bool("critical", false),
+		slog.Duration("elapsed", 2*time.Second),
+		slog.Any("error", errors.New("quota exceeded")),
+		slog.Group("host", slog.String("name", "db-01"), slog.Int("cpu", 8)),
+	)
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Failed to parse JSON: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"level":     "warn",
+		"message":   "disk almost full",
+		"mount":     "/var",
+		"percent":   float64(93),
+		"critical":  false,
+		"elapsed":   float64(2 * time.Second),
+		"error":     "quota exceeded",
+		"host.name": "db-01",
+		"host.cpu":  float64(8),
+	}
+	for key, value := range expected {
+		if entry[key] != value {
+			t.Errorf("Expected %s to be %v, got %v", key, value, entry[key])
+		}
+	}
+}
+
+func TestSlogHandlerWithAttrsAndGroup(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := New(Options{
+		Writer: buf,
+		Level:  DebugLevel,
+	})
+	logger := slog.New(NewSlogHandler(log)).
+		With("service", "api").
+		WithGroup("req").
+		With("id", "req-1")
+
+	logger.Info("handled", "status", 200)
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Failed to parse JSON: %v", err)
+	}
+
+	if entry["service"] != "api" {
+		t.Errorf("Expected service to be 'api', got %v", entry["service"])
+	}
+	if entry["req.id"] != "req-1" {
+		t.Errorf("Expected req.id to be 'req-1', got %v", entry["req.id"])
+	}
+	if entry["req.status"] != float64(200) {
+		t.Errorf("Expected req.status to be 200, got %v", entry["req.status"])
+	}
+}
+
+func TestSlogHandlerWithAttrsSingleChild(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := New(Options{Writer: buf, Level: DebugLevel})
+	handler := NewSlogHandler(log).WithAttrs([]slog.Attr{
+		slog.Int("a", 1),
+		slog.Group("g", slog.String("b", "x"), slog.Bool("c", true)),
+		slog.Any("err", errors.New("boom")),
+	}).(*SlogHandler)
+
+	if handler.logger.parent != log {
+		t.Error("Expected the attributes to be added to a single child logger")
+	}
+	slog.New(handler).Info("handled")
+	if !strings.Contains(buf.String(), `"a":1,"g.b":"x","g.c":true,"err":"boom"`) {
+		t.Errorf("Unexpected line %s", buf.String())
+	}
+}
+
+func TestSlogHandlerEnabled(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := New(Options{
+		Writer: buf,
+		Level:  WarnLevel,
+	})
+	logger := slog.New(NewSlogHandler(log))
+
+	logger.Info("filtered")
+	if buf.Len() > 0 {
+		t.Error("Info record was logged when level is Warn")
+	}
+
+	log.SetLevel(InfoLevel)
+	logger.Info("logged")
+	if buf.Len() == 0 {
+		t.Error("Info record was not logged after lowering the level")
+	}
+}
+
+func TestSlogHandlerFiresHooks(t *testing.T) {
+	log := New(Options{
+		Writer: &bytes.Buffer{},
+		Level:  DebugLevel,
+	})
+	hook := NewMockHook(ErrorLevel)
+	log.AddHook(hook)
+
+	slog.New(NewSlogHandler(log)).Error("failed", "component", "db")
+
+	if len(hook.FiredEntries) != 1 {
+		t.Fatalf("Expected 1 fired entry, got %d", len(hook.FiredEntries))
+	}
+	if hook.FiredEntries[0]["component"] != "db" {
+		t.Errorf("Expected component to be 'db', got %v", hook.FiredEntries[0]["component"])
+	}
+}
+
+func TestSlogLevel(t *testing.T) {
+	tests := []struct {
+		input    slog.Level
+		expected Level
+	}{
+		{slog.LevelDebug - 4, TraceLevel},
+		{slog.LevelDebug, DebugLevel},
+		{slog.LevelInfo, InfoLevel},
+		{slog.LevelInfo + 2, InfoLevel},
+		{slog.LevelWarn, WarnLevel},
+		{slog.LevelError, ErrorLevel},
+		{slog.LevelError + 8, ErrorLevel},
+	}
+
+	for _, test := range tests {
+		if level := SlogLevel(test.input); level != test.expected {
+			t.Errorf("SlogLevel(%v) = %v, want %v", test.input, level, test.expected)
+		}
+	}
+}
+
+func TestSlogHandlerContext(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := New(Options{
+		Writer:            buf,
+		Level:             InfoLevel,
+		ContextExtractors: []ContextExtractor{ContextValue("request_id", testContextKey("request_id"))},
+	})
+	hook := &mockContextHook{MockHook: NewMockHook()}
+	log.AddHook(hook)
+
+	ctx := context.WithValue(context.Background(), testContextKey("request_id"), "req-1")
+	slog.New(NewSlogHandler(log)).InfoContext(ctx, "handled", "status", 200)
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Failed to parse JSON: %v", err)
+	}
+	if entry["request_id"] != "req-1" || entry["status"] != float64(200) {
+		t.Errorf("Expected the context field and the attribute, got %v", entry)
+	}
+	if len(hook.contexts) != 1 || hook.contexts[0] != ctx {
+		t.Errorf("Expected the context hook to receive the record's context, got %v", hook.contexts)
+	}
+}